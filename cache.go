@@ -0,0 +1,237 @@
+// Copyright (c) 2026 Neomantra Corp
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AgentDank/dank-mcp/data"
+	"github.com/AgentDank/dank-mcp/internal/cache"
+	"github.com/AgentDank/dank-mcp/internal/fetch"
+	"github.com/spf13/pflag"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+
+const (
+	cacheUsage = `usage: %s cache <command> [opts]
+
+Commands:
+  ls              List downloaded datasets and debris of unfinished downloads
+  verify [id...]  Re-hash datasets against their manifests and check DuckDB opens them
+  prune           Remove datasets and debris older than --older-than
+  rm <id>...      Remove datasets
+
+`
+
+	defaultPruneAge = 30 * 24 * time.Hour // how old datasets and debris are pruned by default
+)
+
+// runCacheCommand runs the "cache" subcommand with args, the arguments
+// after "cache". Returns the exit code.
+func runCacheCommand(args []string) int {
+	flags := pflag.NewFlagSet("cache", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, cacheUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	var dankRoot string
+	var maxAge, olderThan time.Duration
+	var dryRun bool
+	flags.StringVarP(&dankRoot, "root", "", "", "Set root location of '.dank' dir (Default: current dir)")
+	flags.DurationVarP(&maxAge, "max-age", "", fetch.DefaultMaxAge, "ls: How old a dataset may be and still be listed as fresh")
+	flags.DurationVarP(&olderThan, "older-than", "", defaultPruneAge, "prune: Remove datasets not found current, and debris not written to, for this long")
+	flags.BoolVarP(&dryRun, "dry-run", "n", false, "prune: List what would be removed without removing it")
+	if err := flags.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	if dankRoot != "" {
+		data.SetDankRoot(dankRoot)
+	}
+	cacheDir := data.GetDankCacheDir()
+
+	command, ids := flags.Arg(0), flags.Args()[1:]
+	switch command {
+	case "ls":
+		return cacheList(cacheDir, maxAge)
+	case "verify":
+		return cacheVerify(cacheDir, ids)
+	case "prune":
+		if olderThan < 0 {
+			fmt.Fprintln(os.Stderr, "--older-than must not be negative")
+			return 2
+		}
+		return cachePrune(cacheDir, olderThan, dryRun)
+	case "rm":
+		if len(ids) == 0 {
+			fmt.Fprintln(os.Stderr, "cache rm requires dataset ids")
+			return 2
+		}
+		return cacheRemove(cacheDir, ids)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown cache command %q\n", command)
+		flags.Usage()
+		return 2
+	}
+}
+
+// cacheList prints the datasets and debris in cacheDir.
+func cacheList(cacheDir string, maxAge time.Duration) int {
+	entries, debris, err := cache.Scan(cacheDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return 1
+	}
+	now := time.Now()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSIZE\tAGE\tFRESH\tSHA256")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\n", e.ID, formatBytes(e.Size), formatAge(now.Sub(e.ModTime)),
+			e.Fresh(maxAge, now), e.Manifest.SHA256)
+	}
+	tw.Flush()
+	if len(debris) > 0 {
+		// Aligned separately, as its columns are not the datasets'
+		fmt.Fprintln(os.Stdout)
+		tw = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "DEBRIS\tSIZE\tAGE")
+		for _, d := range debris {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", d.Path, formatBytes(d.Size), formatAge(now.Sub(d.ModTime)))
+		}
+		tw.Flush()
+	}
+	return 0
+}
+
+// listInstalledDatasets prints the datasets in cacheDir with the provenance
+// of their snapshots, for --installed.
+func listInstalledDatasets(cacheDir string) int {
+	entries, _, err := cache.Scan(cacheDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return 1
+	}
+	writeInstalledTable(os.Stdout, entries, time.Now())
+	return 0
+}
+
+// writeInstalledTable writes entries as a table with aligned columns, with
+// how long before now each was downloaded.
+func writeInstalledTable(w io.Writer, entries []cache.Entry, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSIZE\tUPDATED\tDOWNLOADED\tSHA256\tCATALOG")
+	for _, e := range entries {
+		var downloaded string
+		if !e.Manifest.DownloadedAt.IsZero() {
+			downloaded = formatAge(now.Sub(e.Manifest.DownloadedAt)) + " ago"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", e.ID, formatBytes(e.Size), e.Manifest.UpdatedAt, downloaded,
+			e.Manifest.SHA256, e.Manifest.CatalogURL)
+	}
+	return tw.Flush()
+}
+
+// cacheVerify verifies the datasets ids in cacheDir, or all of them if
+// ids is empty. Returns 1 if any fails.
+func cacheVerify(cacheDir string, ids []string) int {
+	entries, _, err := cache.Scan(cacheDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return 1
+	}
+	code := 0
+	for _, id := range ids {
+		if !slices.ContainsFunc(entries, func(e cache.Entry) bool { return e.ID == id }) {
+			fmt.Fprintf(os.Stdout, "%s\tMISSING\n", id)
+			code = 1
+		}
+	}
+	for _, e := range entries {
+		if len(ids) > 0 && !slices.Contains(ids, e.ID) {
+			continue
+		}
+		switch err := cache.Verify(context.Background(), e); {
+		case err != nil:
+			fmt.Fprintf(os.Stdout, "%s\tFAILED\t%s\n", e.ID, err.Error())
+			code = 1
+		case e.Manifest.DuckDBSHA256 == "":
+			fmt.Fprintf(os.Stdout, "%s\tOK\topens; no sha256 recorded to check\n", e.ID)
+		default:
+			fmt.Fprintf(os.Stdout, "%s\tOK\n", e.ID)
+		}
+	}
+	return code
+}
+
+// cachePrune removes datasets and debris in cacheDir older than olderThan.
+func cachePrune(cacheDir string, olderThan time.Duration, dryRun bool) int {
+	removed, err := cache.Prune(cacheDir, olderThan, time.Now(), dryRun)
+	verb := "removed"
+	if dryRun {
+		verb = "would remove"
+	}
+	for _, path := range removed {
+		fmt.Fprintf(os.Stdout, "%s %s\n", verb, path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// cacheRemove removes the datasets ids from cacheDir.
+// Returns 1 if any is not there.
+func cacheRemove(cacheDir string, ids []string) int {
+	code := 0
+	for _, id := range ids {
+		removed, err := cache.Remove(cacheDir, id)
+		for _, path := range removed {
+			fmt.Fprintf(os.Stdout, "removed %s\n", path)
+		}
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			code = 1
+		case len(removed) == 0:
+			fmt.Fprintf(os.Stderr, "dataset %q is not in the cache\n", id)
+			code = 1
+		}
+	}
+	return code
+}
+
+// formatBytes formats n bytes with a binary unit, e.g. "1.5 GiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatAge formats an age in days and hours, or smaller units if under a day.
+func formatAge(age time.Duration) string {
+	if age < 24*time.Hour {
+		return age.Round(time.Minute).String()
+	}
+	days := age / (24 * time.Hour)
+	return fmt.Sprintf("%dd%dh", days, (age-days*24*time.Hour)/time.Hour)
+}
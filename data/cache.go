@@ -7,12 +7,15 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 )
 
 const (
 	DankDir  = ".dank" // DankDir is the directory where dank-mcp stores its data.
 	CacheDir = "cache" // CacheDir is the directory under DankDir where dank-mcp stores its cache files.
+
+	DatasetFilename = "dank-data.duckdb" // DatasetFilename is the name of a downloaded dataset's DuckDB in its cache directory.
 )
 
 var dankRoot string = "." // The root directory for dank-mcp data, default is '.'
@@ -94,7 +97,7 @@ func MakeCacheFile(filename string) (*os.File, error) {
 // GetDatasetCachePath returns the canonical on-disk path for a dataset's
 // downloaded DuckDB under the dank root: .dank/cache/<id>/dank-data.duckdb
 func GetDatasetCachePath(id string) string {
-	return filepath.Join(GetDankCacheDir(), filepath.FromSlash(id), "dank-data.duckdb")
+	return filepath.Join(GetDankCacheDir(), filepath.FromSlash(id), DatasetFilename)
 }
 
 // datasetIDPattern matches the expected shape of a dank-data dataset id:
@@ -111,3 +114,9 @@ func ValidateDatasetID(id string) error {
 	}
 	return nil
 }
+
+// DatasetAlias returns the name under which a dataset is attached as a
+// database, derived from its id, e.g. "us_ct" for "us/ct".
+func DatasetAlias(id string) string {
+	return strings.NewReplacer("/", "_", "-", "_").Replace(id)
+}
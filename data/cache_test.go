@@ -49,3 +49,16 @@ func TestValidateDatasetID(t *testing.T) {
 		}
 	}
 }
+
+func TestDatasetAlias(t *testing.T) {
+	cases := map[string]string{
+		"us/ct":           "us_ct",
+		"us/weekly_sales": "us_weekly_sales",
+		"us/with-hyphens": "us_with_hyphens",
+	}
+	for id, want := range cases {
+		if got := DatasetAlias(id); got != want {
+			t.Errorf("DatasetAlias(%q) = %q; want %q", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2026 Neomantra Corp
+
+// Package cache inspects and cleans up the datasets downloaded into the
+// dank cache directory, and the debris of interrupted downloads.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AgentDank/dank-mcp/data"
+	"github.com/AgentDank/dank-mcp/internal/fetch"
+	_ "github.com/duckdb/duckdb-go/v2"
+)
+
+// debrisSuffixes are the suffixes, after a dataset's DuckDB filename, of
+// the files left by downloads that did not finish.
+var debrisSuffixes = []string{
+	".zst.partial",      // partial download, kept for resuming
+	".zst.partial.json", // its sidecar
+	".new",              // decompressed but not installed
+	".meta.json.new",    // manifest not installed
+}
+
+// Entry is a dataset installed in the cache.
+type Entry struct {
+	ID      string    // Dataset id, e.g. "us/ct"
+	Path    string    // Path of its installed DuckDB
+	Size    int64     // Size of its installed DuckDB, in bytes
+	ModTime time.Time // When it was installed, or last found current
+
+	// Manifest is the provenance of the installed snapshot; HasManifest
+	// is false if it was installed without one.
+	Manifest    fetch.Manifest
+	HasManifest bool
+}
+
+// Fresh returns whether e would be used without checking the catalog,
+// under fetch.RefreshTTL with the given max age.
+func (e Entry) Fresh(maxAge time.Duration, now time.Time) bool {
+	return now.Sub(e.ModTime) < maxAge
+}
+
+// Debris is a file left in the cache by a download that did not finish.
+type Debris struct {
+	ID      string // Dataset id the download was for
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Scan returns the datasets installed in cacheDir and the debris there,
+// each sorted by path. A missing cacheDir is empty.
+func Scan(cacheDir string) ([]Entry, []Debris, error) {
+	var entries []Entry
+	var debris []Debris
+	err := filepath.WalkDir(cacheDir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == cacheDir {
+			return filepath.SkipDir
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(cacheDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		id := filepath.ToSlash(rel)
+		if data.ValidateDatasetID(id) != nil {
+			return nil // not in a dataset's directory
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		name := d.Name()
+		if name == data.DatasetFilename {
+			entry := Entry{ID: id, Path: path, Size: info.Size(), ModTime: info.ModTime()}
+			manifest, err := fetch.ReadManifest(path)
+			switch {
+			case err == nil:
+				entry.Manifest, entry.HasManifest = manifest, true
+			case !errors.Is(err, fs.ErrNotExist):
+				return fmt.Errorf("dataset %s: %w", id, err)
+			}
+			entries = append(entries, entry)
+			return nil
+		}
+		for _, suffix := range debrisSuffixes {
+			if name == data.DatasetFilename+suffix {
+				debris = append(debris, Debris{ID: id, Path: path, Size: info.Size(), ModTime: info.ModTime()})
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("scan cache: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	sort.Slice(debris, func(i, j int) bool { return debris[i].Path < debris[j].Path })
+	return entries, debris, nil
+}
+
+// Verify checks that e's DuckDB has the sha256 recorded in its manifest
+// when it was installed, and that DuckDB can open it and read its catalog.
+func Verify(ctx context.Context, e Entry) error {
+	if e.HasManifest && e.Manifest.DuckDBSHA256 != "" {
+		got, err := hashFile(e.Path)
+		if err != nil {
+			return err
+		}
+		if got != e.Manifest.DuckDBSHA256 {
+			return fmt.Errorf("sha256 mismatch: manifest has %s, file has %s", e.Manifest.DuckDBSHA256, got)
+		}
+	}
+
+	conn, err := sql.Open("duckdb", e.Path+"?access_mode=read_only")
+	if err != nil {
+		return fmt.Errorf("open DuckDB: %w", err)
+	}
+	defer conn.Close()
+	var tables int
+	if err := conn.QueryRowContext(ctx, "SELECT count(*) FROM duckdb_tables()").Scan(&tables); err != nil {
+		return fmt.Errorf("read DuckDB: %w", err)
+	}
+	return nil
+}
+
+// hashFile returns the hex sha256 of the file at path.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Remove removes dataset id from cacheDir: its DuckDB, manifest and any
+// debris, and then its directory if that is left empty. Returns the paths
+// removed, which are none if id is not in the cache.
+func Remove(cacheDir, id string) ([]string, error) {
+	if err := data.ValidateDatasetID(id); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(cacheDir, filepath.FromSlash(id))
+	names := []string{data.DatasetFilename, data.DatasetFilename + ".meta.json"}
+	for _, suffix := range debrisSuffixes {
+		names = append(names, data.DatasetFilename+suffix)
+	}
+	var removed []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if ok, err := removeFile(path); err != nil {
+			return removed, err
+		} else if ok {
+			removed = append(removed, path)
+		}
+	}
+	removeEmptyDirs(cacheDir, dir)
+	return removed, nil
+}
+
+// Prune removes the datasets in cacheDir not found current within
+// olderThan of now, and debris not written to within olderThan, as Remove
+// does. With dryRun, nothing is removed. Returns the paths removed, or
+// that would be.
+func Prune(cacheDir string, olderThan time.Duration, now time.Time, dryRun bool) ([]string, error) {
+	entries, debris, err := Scan(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, e := range entries {
+		if now.Sub(e.ModTime) < olderThan {
+			continue
+		}
+		if dryRun {
+			removed = append(removed, e.Path)
+			continue
+		}
+		paths, err := Remove(cacheDir, e.ID)
+		removed = append(removed, paths...)
+		if err != nil {
+			return removed, err
+		}
+	}
+	for _, d := range debris {
+		if now.Sub(d.ModTime) < olderThan || slices.Contains(removed, d.Path) {
+			continue
+		}
+		if !dryRun {
+			if _, err := removeFile(d.Path); err != nil {
+				return removed, err
+			}
+			removeEmptyDirs(cacheDir, filepath.Dir(d.Path))
+		}
+		removed = append(removed, d.Path)
+	}
+	return removed, nil
+}
+
+// removeFile removes the file at path, returning whether it existed.
+func removeFile(path string) (bool, error) {
+	err := os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("remove %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// removeEmptyDirs removes dir, and then its parents up to cacheDir, for as
+// long as they are empty.
+func removeEmptyDirs(cacheDir, dir string) {
+	for dir != cacheDir && strings.HasPrefix(dir, cacheDir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
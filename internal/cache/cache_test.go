@@ -0,0 +1,180 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AgentDank/dank-mcp/internal/fetch"
+)
+
+// installDataset creates a DuckDB for id in cacheDir, with a manifest
+// recording its sha256, last found current age ago.
+func installDataset(t *testing.T, cacheDir, id string, age time.Duration) string {
+	t.Helper()
+	path := filepath.Join(cacheDir, filepath.FromSlash(id), "dank-data.duckdb")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := sql.Open("duckdb", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec("CREATE TABLE brands (brand_name VARCHAR)"); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	sum, err := hashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, _ := json.Marshal(fetch.Manifest{ID: id, SHA256: "abc", DuckDBSHA256: sum})
+	if err := os.WriteFile(path+".meta.json", manifest, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	setAge(t, path, age)
+	return path
+}
+
+func writeFile(t *testing.T, path string, age time.Duration) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("debris"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	setAge(t, path, age)
+}
+
+func setAge(t *testing.T, path string, age time.Duration) {
+	t.Helper()
+	at := time.Now().Add(-age)
+	if err := os.Chtimes(path, at, at); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	cacheDir := t.TempDir()
+	ctPath := installDataset(t, cacheDir, "us/ct", time.Hour)
+	writeFile(t, filepath.Join(cacheDir, "us", "ma", "dank-data.duckdb"), 10*24*time.Hour) // no manifest
+	writeFile(t, ctPath+".zst.partial", time.Hour)
+	writeFile(t, filepath.Join(cacheDir, "us", "nv", "dank-data.duckdb.new"), time.Hour)
+	writeFile(t, filepath.Join(cacheDir, "notes.txt"), time.Hour)
+
+	entries, debris, err := Scan(cacheDir)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != "us/ct" || !entries[0].HasManifest ||
+		entries[1].ID != "us/ma" || entries[1].HasManifest {
+		t.Fatalf("entries = %+v", entries)
+	}
+	now := time.Now()
+	if !entries[0].Fresh(fetch.DefaultMaxAge, now) || entries[1].Fresh(fetch.DefaultMaxAge, now) {
+		t.Error("unexpected freshness")
+	}
+	if len(debris) != 2 || debris[0].ID != "us/ct" || debris[1].ID != "us/nv" {
+		t.Errorf("debris = %+v", debris)
+	}
+
+	if entries, debris, err := Scan(filepath.Join(cacheDir, "missing")); err != nil || len(entries)+len(debris) != 0 {
+		t.Errorf("missing cache dir: %v, %v, %v", entries, debris, err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	cacheDir := t.TempDir()
+	installDataset(t, cacheDir, "us/ct", time.Hour)
+	maPath := installDataset(t, cacheDir, "us/ma", time.Hour)
+	f, _ := os.OpenFile(maPath, os.O_WRONLY|os.O_APPEND, 0)
+	f.Write([]byte("tampered"))
+	f.Close()
+	writeFile(t, filepath.Join(cacheDir, "us", "nv", "dank-data.duckdb"), time.Hour) // no manifest, not a DuckDB
+
+	entries, _, err := Scan(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"us/ct": true, "us/ma": false, "us/nv": false}
+	for _, e := range entries {
+		if err := Verify(context.Background(), e); (err == nil) != want[e.ID] {
+			t.Errorf("Verify(%s) = %v; want ok %v", e.ID, err, want[e.ID])
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	cacheDir := t.TempDir()
+	ctPath := installDataset(t, cacheDir, "us/ct", time.Hour)
+	writeFile(t, ctPath+".zst.partial", time.Hour)
+	writeFile(t, ctPath+".zst.partial.json", time.Hour)
+	installDataset(t, cacheDir, "us/ma", time.Hour)
+
+	removed, err := Remove(cacheDir, "us/ct")
+	if err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if len(removed) != 4 {
+		t.Errorf("removed %v", removed)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "us", "ct")); !os.IsNotExist(err) {
+		t.Error("dataset directory not removed")
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "us", "ma", "dank-data.duckdb")); err != nil {
+		t.Errorf("other dataset removed: %v", err)
+	}
+
+	if removed, err := Remove(cacheDir, "us/ct"); err != nil || len(removed) != 0 {
+		t.Errorf("Remove again: %v, %v", removed, err)
+	}
+	if _, err := Remove(cacheDir, "../etc"); err == nil {
+		t.Error("expected error for invalid id")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	cacheDir := t.TempDir()
+	ctPath := installDataset(t, cacheDir, "us/ct", time.Hour)
+	maPath := installDataset(t, cacheDir, "us/ma", 40*24*time.Hour)
+	writeFile(t, ctPath+".zst.partial", time.Hour)                                                     // resumable
+	writeFile(t, ctPath+".new", 40*24*time.Hour)                                                       // crashed install
+	writeFile(t, filepath.Join(cacheDir, "us", "nv", "dank-data.duckdb.zst.partial"), 40*24*time.Hour) // abandoned
+
+	olderThan := 30 * 24 * time.Hour
+	dryRun, err := Prune(cacheDir, olderThan, time.Now(), true)
+	if err != nil {
+		t.Fatalf("Prune dry run: %v", err)
+	}
+	if len(dryRun) != 3 {
+		t.Errorf("dry run = %v", dryRun)
+	}
+	if _, err := os.Stat(maPath); err != nil {
+		t.Fatalf("dry run removed %s", maPath)
+	}
+
+	if _, err := Prune(cacheDir, olderThan, time.Now(), false); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	entries, debris, err := Scan(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ID != "us/ct" {
+		t.Errorf("entries after prune = %+v", entries)
+	}
+	if len(debris) != 1 || debris[0].Path != ctPath+".zst.partial" {
+		t.Errorf("debris after prune = %+v", debris)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "us", "nv")); !os.IsNotExist(err) {
+		t.Error("empty dataset directory not removed")
+	}
+}
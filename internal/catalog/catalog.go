@@ -6,21 +6,33 @@ package catalog
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 	"time"
+
+	"github.com/AgentDank/dank-mcp/internal/retry"
+	"golang.org/x/mod/semver"
 )
 
-const maxCatalogSize = 10 * 1024 * 1024 // 10 MiB
+const (
+	maxCatalogSize  = 10 * 1024 * 1024 // 10 MiB
+	catalogFilename = "catalog.json"   // name of the catalog in a catalog directory
+)
 
 var defaultClient = &http.Client{
 	Timeout: 30 * time.Second,
 }
 
 const (
-	currentVersion = 1
+	currentVersion = 2 // version written by Merge, and the latest read
+	oldestVersion  = 1 // oldest version read
 
 	// DefaultURL is the well-known location of the dank-data catalog.
 	DefaultURL = "https://raw.githubusercontent.com/AgentDank/dank-data/main/snapshots/catalog.json"
@@ -39,6 +51,81 @@ type DatasetEntry struct {
 	DuckDBURL   string `json:"duckdb_url"`
 	SHA256      string `json:"sha256"`
 	UpdatedAt   string `json:"updated_at,omitempty"`
+
+	// Metadata is added by catalog version 2; it is empty in version 1.
+	Metadata
+
+	// Source is the location of the catalog the entry was fetched from.
+	Source string `json:"-"`
+	// KeyID is the id of the key that catalog's signature was verified
+	// with, or empty if it was not verified.
+	KeyID string `json:"-"`
+}
+
+// Metadata describes what a dataset contains and the terms it is
+// published under, so that it can be chosen before it is downloaded.
+type Metadata struct {
+	// CompressedSize is the size of the snapshot in bytes, and
+	// UncompressedSize the size of the DuckDB it decompresses to.
+	CompressedSize   int64 `json:"compressed_size,omitempty"`
+	UncompressedSize int64 `json:"uncompressed_size,omitempty"`
+
+	// Attribution credits the source of the data, and License is the
+	// license it is published under, as an SPDX identifier where one exists.
+	Attribution string `json:"attribution,omitempty"`
+	License     string `json:"license,omitempty"`
+
+	// Jurisdiction is the ISO 3166 code of the region the data covers,
+	// e.g. "US-CT".
+	Jurisdiction string   `json:"jurisdiction,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+
+	// Tables are the tables in the DuckDB.
+	Tables []Table `json:"tables,omitempty"`
+
+	// MinVersion is the oldest version of dank-mcp that can use the
+	// dataset, e.g. "v0.4.0".
+	MinVersion string `json:"min_version,omitempty"`
+
+	// BindingsURL is the location of bindings of tools, resources and
+	// prompts for the dataset, resolved against the catalog's location.
+	BindingsURL string `json:"bindings_url,omitempty"`
+}
+
+// Table describes a table in a dataset.
+type Table struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// TableNames returns the names of m's tables.
+func (m Metadata) TableNames() []string {
+	names := make([]string, len(m.Tables))
+	for i, table := range m.Tables {
+		names[i] = table.Name
+	}
+	return names
+}
+
+// CheckVersion returns an error if m requires a later version of dank-mcp
+// than version. Development builds, whose versions are not semantic
+// versions, are assumed to be recent enough.
+func (m Metadata) CheckVersion(version string) error {
+	if m.MinVersion == "" || !semver.IsValid(canonicalVersion(version)) {
+		return nil
+	}
+	if semver.Compare(canonicalVersion(version), canonicalVersion(m.MinVersion)) < 0 {
+		return fmt.Errorf("requires dank-mcp %s or later, but this is %s; please upgrade dank-mcp", m.MinVersion, version)
+	}
+	return nil
+}
+
+// canonicalVersion returns version with the "v" prefix semver requires.
+func canonicalVersion(version string) string {
+	if strings.HasPrefix(version, "v") {
+		return version
+	}
+	return "v" + version
 }
 
 // Parse decodes a catalog.json body and validates the required fields.
@@ -47,8 +134,8 @@ func Parse(body []byte) (Catalog, error) {
 	if err := json.Unmarshal(body, &c); err != nil {
 		return Catalog{}, fmt.Errorf("decode catalog: %w", err)
 	}
-	if c.Version != currentVersion {
-		return Catalog{}, fmt.Errorf("unsupported catalog version %d (expected %d); please upgrade dank-mcp", c.Version, currentVersion)
+	if c.Version < oldestVersion || c.Version > currentVersion {
+		return Catalog{}, fmt.Errorf("unsupported catalog version %d (expected %d to %d); please upgrade dank-mcp", c.Version, oldestVersion, currentVersion)
 	}
 	for id, entry := range c.Datasets {
 		if entry.DuckDBURL == "" {
@@ -57,6 +144,17 @@ func Parse(body []byte) (Catalog, error) {
 		if entry.SHA256 == "" {
 			return Catalog{}, fmt.Errorf("dataset %q missing required field sha256", id)
 		}
+		if entry.CompressedSize < 0 || entry.UncompressedSize < 0 {
+			return Catalog{}, fmt.Errorf("dataset %q has a negative size", id)
+		}
+		for _, table := range entry.Tables {
+			if table.Name == "" {
+				return Catalog{}, fmt.Errorf("dataset %q has a table without a name", id)
+			}
+		}
+		if entry.MinVersion != "" && !semver.IsValid(canonicalVersion(entry.MinVersion)) {
+			return Catalog{}, fmt.Errorf("dataset %q has invalid min_version %q", id, entry.MinVersion)
+		}
 	}
 	return c, nil
 }
@@ -75,30 +173,249 @@ func (c Catalog) Lookup(id string) (DatasetEntry, error) {
 	return DatasetEntry{}, fmt.Errorf("unknown dataset %q; known ids: %v", id, known)
 }
 
-// Fetch retrieves and parses the catalog at url. Pass nil for client to use
-// http.DefaultClient.
+// ErrNotModified is returned by conditional requests when the server
+// reports that the resource has not changed since the Validators were
+// recorded.
+var ErrNotModified = errors.New("not modified")
+
+// Validators are the HTTP cache validators of a response, recorded so a
+// later request can be made conditional on the resource having changed.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// ValidatorsOf returns the validators of resp.
+func ValidatorsOf(resp *http.Response) Validators {
+	return Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+}
+
+// IsZero returns whether v has no validators.
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// SetConditional makes req conditional on the resource having changed
+// since v was recorded, using If-None-Match and If-Modified-Since.
+func (v Validators) SetConditional(req *http.Request) {
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+// Location returns the catalog location named by s, which is either an
+// http(s) URL, a file:// URL, a path to a catalog file, a path to a
+// directory containing a catalog.json, or "default" for DefaultURL.
+// Local catalogs are returned as absolute file:// URLs.
+func Location(s string) (string, error) {
+	if s == "default" {
+		return DefaultURL, nil
+	}
+	if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") {
+		if _, err := url.Parse(s); err != nil {
+			return "", fmt.Errorf("invalid catalog URL %q: %w", s, err)
+		}
+		return s, nil
+	}
+	path := s
+	if strings.HasPrefix(s, "file://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid catalog URL %q: %w", s, err)
+		}
+		path = FilePath(u)
+	} else if strings.Contains(s, "://") {
+		return "", fmt.Errorf("unsupported catalog URL %q: expected http, https or file", s)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid catalog path %q: %w", s, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("catalog %q: %w", s, err)
+	}
+	if info.IsDir() {
+		path = filepath.Join(path, catalogFilename)
+	}
+	return FileURL(path), nil
+}
+
+// FileURL returns the file:// URL of path, an absolute path. A path with
+// a volume name, such as C:\x on Windows, is given a leading slash as in
+// file:///C:/x, so that its volume is not parsed as the URL's host.
+func FileURL(path string) string {
+	slashed := filepath.ToSlash(path)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed
+	}
+	return (&url.URL{Scheme: "file", Path: slashed}).String()
+}
+
+// FilePath returns the path of u, a file:// URL as made by FileURL,
+// stripping the slash before a drive letter, as in file:///C:/x.
+func FilePath(u *url.URL) string {
+	path := u.Path
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' && isLetter(path[1]) {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// Fetch retrieves and parses the catalog at url, retrying transient
+// failures with retry.DefaultPolicy, without verifying its signature.
+// Pass nil for client to use http.DefaultClient.
 func Fetch(ctx context.Context, url string, client *http.Client) (Catalog, error) {
+	cat, _, err := FetchIfModified(ctx, url, client, retry.DefaultPolicy, nil, Validators{})
+	return cat, err
+}
+
+// FetchIfModified is Fetch with the retry policy, conditional on the
+// catalog having changed since prev was recorded, returning ErrNotModified
+// if it has not. Also returns the validators of the fetched catalog, for
+// the next call. Unless keys is nil, the catalog must be signed by one of
+// them, or an error wrapping ErrUnverified is returned.
+func FetchIfModified(ctx context.Context, url string, client *http.Client, policy retry.Policy, keys Keyring, prev Validators) (Catalog, Validators, error) {
 	if client == nil {
 		client = defaultClient
 	}
+	var body []byte
+	var validators Validators
+	var err error
+	if strings.HasPrefix(url, "file://") {
+		body, validators, err = readFileIfModified(url, prev, maxCatalogSize)
+	} else {
+		body, validators, err = getIfModified(ctx, url, client, policy, prev, maxCatalogSize)
+	}
+	if err != nil {
+		if !errors.Is(err, ErrNotModified) {
+			err = fmt.Errorf("fetch catalog: %w", err)
+		}
+		return Catalog{}, validators, err
+	}
+	var keyID string
+	if keys != nil {
+		if keyID, err = verifySignature(ctx, url, client, policy, keys, body); err != nil {
+			return Catalog{}, Validators{}, err
+		}
+	}
+	cat, err := parseFrom(body, url, keyID)
+	if err != nil {
+		return Catalog{}, Validators{}, err
+	}
+	return cat, validators, nil
+}
+
+// getIfModified GETs url, conditional on it having changed since prev was
+// recorded, returning ErrNotModified if it has not. Returns the body, of
+// at most maxSize bytes, and its validators.
+func getIfModified(ctx context.Context, url string, client *http.Client, policy retry.Policy, prev Validators, maxSize int64) ([]byte, Validators, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return Catalog{}, fmt.Errorf("build catalog request: %w", err)
+		return nil, Validators{}, fmt.Errorf("build request: %w", err)
 	}
-	resp, err := client.Do(req)
+	prev.SetConditional(req)
+	resp, err := policy.Do(client, req)
 	if err != nil {
-		return Catalog{}, fmt.Errorf("fetch catalog: %w", err)
+		return nil, Validators{}, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && !prev.IsZero() {
+		return nil, prev, ErrNotModified
+	}
 	if resp.StatusCode != http.StatusOK {
-		return Catalog{}, fmt.Errorf("fetch catalog: HTTP %d", resp.StatusCode)
+		return nil, Validators{}, fmt.Errorf("%s: HTTP %d", url, resp.StatusCode)
+	}
+	if resp.ContentLength > maxSize {
+		return nil, Validators{}, fmt.Errorf("%s: response too large (%d bytes)", url, resp.ContentLength)
 	}
-	if resp.ContentLength > maxCatalogSize {
-		return Catalog{}, fmt.Errorf("fetch catalog: response too large (%d bytes)", resp.ContentLength)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize))
+	if err != nil {
+		return nil, Validators{}, fmt.Errorf("read %s: %w", url, err)
+	}
+	return body, ValidatorsOf(resp), nil
+}
+
+// readFileIfModified is getIfModified for a file:// URL. The file's
+// modification time serves as its Last-Modified validator.
+func readFileIfModified(location string, prev Validators, maxSize int64) ([]byte, Validators, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, Validators{}, fmt.Errorf("invalid URL %q: %w", location, err)
+	}
+	path := FilePath(u)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, Validators{}, err
+	}
+	validators := Validators{LastModified: info.ModTime().UTC().Format(http.TimeFormat)}
+	if prev.LastModified != "" && prev == validators {
+		return nil, prev, ErrNotModified
+	}
+	if info.Size() > maxSize {
+		return nil, Validators{}, fmt.Errorf("%s: file too large (%d bytes)", path, info.Size())
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, Validators{}, err
+	}
+	return body, validators, nil
+}
+
+// parseFrom parses body, the catalog at location, as the Source of its
+// entries, resolving their duckdb_url and bindings_url against location. keyID is the id
+// of the key its signature was verified with, if any.
+func parseFrom(body []byte, location, keyID string) (Catalog, error) {
+	cat, err := Parse(body)
+	if err != nil {
+		return Catalog{}, err
+	}
+	for id, entry := range cat.Datasets {
+		entry.Source, entry.KeyID = location, keyID
+		if entry.DuckDBURL, err = resolveURL(location, entry.DuckDBURL); err != nil {
+			return Catalog{}, fmt.Errorf("dataset %q: duckdb_url: %w", id, err)
+		}
+		if entry.BindingsURL != "" {
+			if entry.BindingsURL, err = resolveURL(location, entry.BindingsURL); err != nil {
+				return Catalog{}, fmt.Errorf("dataset %q: bindings_url: %w", id, err)
+			}
+		}
+		cat.Datasets[id] = entry
 	}
-	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCatalogSize))
+	return cat, nil
+}
+
+// resolveURL resolves ref, a duckdb_url or bindings_url which may be
+// relative, against the location of its catalog. These may be http(s)
+// URLs, or file:// URLs if the catalog is itself local.
+func resolveURL(location, ref string) (string, error) {
+	base, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid catalog URL %q: %w", location, err)
+	}
+	refURL, err := url.Parse(ref)
 	if err != nil {
-		return Catalog{}, fmt.Errorf("read catalog body: %w", err)
+		return "", fmt.Errorf("invalid URL %q: %w", ref, err)
+	}
+	resolved := base.ResolveReference(refURL)
+	switch resolved.Scheme {
+	case "http", "https":
+	case "file":
+		if base.Scheme != "file" {
+			return "", fmt.Errorf("%q: a remote catalog may not refer to local files", ref)
+		}
+	default:
+		return "", fmt.Errorf("unsupported URL %q: expected http, https or file", ref)
 	}
-	return Parse(body)
+	return resolved.String(), nil
 }
@@ -4,10 +4,16 @@ package catalog
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/AgentDank/dank-mcp/internal/retry"
 )
 
 const validCatalog = `{
@@ -82,6 +88,59 @@ func TestParse_IgnoresUnknownFields(t *testing.T) {
 	}
 }
 
+func TestParse_V2(t *testing.T) {
+	body := strings.NewReplacer(`"version": 1`, `"version": 2`,
+		`"updated_at": "2026-04-19T00:00:00Z"`, `"updated_at": "2026-04-19T00:00:00Z",
+      "compressed_size": 1024, "uncompressed_size": 4096,
+      "attribution": "CT Open Data", "license": "CC0-1.0", "jurisdiction": "US-CT", "tags": ["retail", "lab-tests"],
+      "tables": [{"name": "brands", "description": "Registered brands"}, {"name": "tests"}],
+      "min_version": "0.4.0", "bindings_url": "https://example.com/us/ct/bindings.json"`).Replace(validCatalog)
+	cat, err := Parse([]byte(body))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	entry := cat.Datasets["us/ct"]
+	if entry.CompressedSize != 1024 || entry.UncompressedSize != 4096 || entry.License != "CC0-1.0" ||
+		entry.Jurisdiction != "US-CT" || len(entry.Tags) != 2 || entry.MinVersion != "0.4.0" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if got := strings.Join(entry.TableNames(), ","); got != "brands,tests" {
+		t.Errorf("TableNames = %q", got)
+	}
+
+	for name, field := range map[string]string{
+		"table without a name": `"tables": [{"description": "x"}]`,
+		"invalid min_version":  `"min_version": "soon"`,
+		"negative size":        `"compressed_size": -1`,
+	} {
+		body := strings.Replace(validCatalog, `"updated_at": "2026-04-19T00:00:00Z"`, `"updated_at": "2026-04-19T00:00:00Z", `+field, 1)
+		if _, err := Parse([]byte(body)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		minVersion, version string
+		wantErr             bool
+	}{
+		{"", "0.1.0", false},
+		{"0.4.0", "0.4.0", false},
+		{"v0.4.0", "v0.5.1", false},
+		{"0.4.0", "0.3.9", true},
+		{"v1.0.0", "v0.9.0", true},
+		{"v1.0.0", "dev-1a2b3c4", false},
+		{"v1.0.0", "dev", false},
+	}
+	for _, tt := range tests {
+		err := Metadata{MinVersion: tt.minVersion}.CheckVersion(tt.version)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckVersion(%q) with min_version %q: %v; want error %v", tt.version, tt.minVersion, err, tt.wantErr)
+		}
+	}
+}
+
 func TestLookup_Hit(t *testing.T) {
 	cat, _ := Parse([]byte(validCatalog))
 	entry, err := cat.Lookup("us/ct")
@@ -135,3 +194,203 @@ func TestFetch_404(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestFetchIfModified(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Sun, 19 Apr 2026 00:00:00 GMT")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(validCatalog))
+	}))
+	defer srv.Close()
+
+	_, validators, err := FetchIfModified(context.Background(), srv.URL, srv.Client(), retry.DefaultPolicy, nil, Validators{})
+	if err != nil {
+		t.Fatalf("FetchIfModified: %v", err)
+	}
+	want := Validators{ETag: `"v1"`, LastModified: "Sun, 19 Apr 2026 00:00:00 GMT"}
+	if validators != want {
+		t.Errorf("validators = %+v; want %+v", validators, want)
+	}
+
+	_, _, err = FetchIfModified(context.Background(), srv.URL, srv.Client(), retry.DefaultPolicy, nil, validators)
+	if !errors.Is(err, ErrNotModified) {
+		t.Errorf("expected ErrNotModified, got %v", err)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	dir := t.TempDir()
+	catalogPath := filepath.Join(dir, "catalog.json")
+	if err := os.WriteFile(catalogPath, []byte(validCatalog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fileURL := "file://" + filepath.ToSlash(catalogPath)
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{"default", DefaultURL, false},
+		{"https://example.com/catalog.json", "https://example.com/catalog.json", false},
+		{"http://localhost:8080/c.json", "http://localhost:8080/c.json", false},
+		{dir, fileURL, false},
+		{catalogPath, fileURL, false},
+		{"file://" + dir, fileURL, false},
+		{fileURL, fileURL, false},
+		{filepath.Join(dir, "missing"), "", true},
+		{"s3://bucket/catalog.json", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Location(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Location(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFileURL(t *testing.T) {
+	tests := []struct {
+		path, url string
+	}{
+		{"/x/catalog.json", "file:///x/catalog.json"},
+		{"/x/my data/catalog.json", "file:///x/my%20data/catalog.json"},
+		// a volume-qualified path, as filepath.ToSlash gives on Windows
+		{"C:/x/catalog.json", "file:///C:/x/catalog.json"},
+	}
+	for _, tt := range tests {
+		got := FileURL(tt.path)
+		if got != tt.url {
+			t.Errorf("FileURL(%q) = %q; want %q", tt.path, got, tt.url)
+		}
+		u, err := url.Parse(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Host != "" || FilePath(u) != filepath.FromSlash(tt.path) {
+			t.Errorf("FilePath(%q) = %q, host %q; want %q", got, FilePath(u), u.Host, tt.path)
+		}
+		// snapshots are resolved relative to the catalog
+		resolved, err := resolveURL(got, "us/ct.duckdb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u, _ = url.Parse(resolved); FilePath(u) != filepath.FromSlash(strings.TrimSuffix(tt.path, "catalog.json")+"us/ct.duckdb") {
+			t.Errorf("resolved %q to path %q", resolved, FilePath(u))
+		}
+	}
+}
+
+func TestFetchIfModified_File(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "catalog.json"), []byte(validCatalog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	location, err := Location(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cat, validators, err := FetchIfModified(context.Background(), location, nil, retry.DefaultPolicy, nil, Validators{})
+	if err != nil {
+		t.Fatalf("FetchIfModified: %v", err)
+	}
+	if entry := cat.Datasets["us/ct"]; entry.Source != location {
+		t.Errorf("Source = %q; want %q", entry.Source, location)
+	}
+	if validators.LastModified == "" {
+		t.Error("expected modification time as validator")
+	}
+	if _, _, err := FetchIfModified(context.Background(), location, nil, retry.DefaultPolicy, nil, validators); !errors.Is(err, ErrNotModified) {
+		t.Errorf("expected ErrNotModified, got %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	first := Catalog{Datasets: map[string]DatasetEntry{
+		"us/ct": {Title: "Internal CT", Source: "file:///internal/catalog.json"},
+		"us/zz": {Title: "Internal only", Source: "file:///internal/catalog.json"},
+	}}
+	second := Catalog{Datasets: map[string]DatasetEntry{
+		"us/ct": {Title: "Public CT", Source: DefaultURL},
+		"us/ma": {Title: "Public MA", Source: DefaultURL},
+	}}
+	merged, shadowed := Merge(first, second)
+	if len(merged.Datasets) != 3 {
+		t.Errorf("merged %d datasets; want 3", len(merged.Datasets))
+	}
+	if got := merged.Datasets["us/ct"].Title; got != "Internal CT" {
+		t.Errorf("us/ct from %q; want the first catalog's", got)
+	}
+	want := []Shadowed{{ID: "us/ct", Source: DefaultURL, By: "file:///internal/catalog.json"}}
+	if len(shadowed) != 1 || shadowed[0] != want[0] {
+		t.Errorf("shadowed = %+v; want %+v", shadowed, want)
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		location, duckdbURL, want string
+		wantErr                   bool
+	}{
+		{"https://example.com/snapshots/catalog.json", "https://cdn.example.com/ct.zst", "https://cdn.example.com/ct.zst", false},
+		{"https://example.com/snapshots/catalog.json", "us/ct/dank-data.duckdb.zst", "https://example.com/snapshots/us/ct/dank-data.duckdb.zst", false},
+		{"https://example.com/snapshots/catalog.json", "/other/ct.zst", "https://example.com/other/ct.zst", false},
+		{"file:///mnt/usb/catalog.json", "us/ct/dank-data.duckdb.zst", "file:///mnt/usb/us/ct/dank-data.duckdb.zst", false},
+		{"file:///mnt/usb/catalog.json", "../nfs/ct.zst", "file:///mnt/nfs/ct.zst", false},
+		{"file:///mnt/usb/catalog.json", "file:///srv/ct.zst", "file:///srv/ct.zst", false},
+		{"file:///mnt/usb/catalog.json", "https://example.com/ct.zst", "https://example.com/ct.zst", false},
+		{"https://example.com/catalog.json", "file:///etc/passwd", "", true},
+		{"https://example.com/catalog.json", "ftp://example.com/ct.zst", "", true},
+	}
+	for _, tt := range tests {
+		got, err := resolveURL(tt.location, tt.duckdbURL)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("resolveURL(%q, %q) = %q, %v; want %q, error %v",
+				tt.location, tt.duckdbURL, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	ct := DatasetEntry{Title: "Connecticut", Description: "Brands and lab tests", Metadata: Metadata{
+		Jurisdiction: "US-CT", Tags: []string{"retail"}, Attribution: "CT Data Portal", License: "CC0-1.0",
+		Tables: []Table{{Name: "tests", Description: "THC potency by lab"}},
+	}}
+	tests := []struct {
+		filter Filter
+		id     string
+		want   bool
+	}{
+		{Filter{}, "us/ct", true},
+		{Filter{Regions: []string{"us"}}, "us/ct", true},
+		{Filter{Regions: []string{"US/"}}, "us/ct", true},
+		{Filter{Regions: []string{"us/ct"}}, "us/ct", true},
+		{Filter{Regions: []string{"us-ct"}}, "internal/ct", true},
+		{Filter{Regions: []string{"u"}}, "us/ct", false},
+		{Filter{Regions: []string{"us/c"}}, "us/ct", false},
+		{Filter{Regions: []string{"US-C"}}, "us/ct", false},
+		{Filter{Regions: []string{"US-CT"}}, "us/ct", true},
+		{Filter{Regions: []string{"us/ct/"}}, "us/ct", true},
+		{Filter{Regions: []string{""}}, "us/ct", false},
+		{Filter{Regions: []string{"/"}}, "us/ct", false},
+		{Filter{Regions: []string{"ca", "us"}}, "us/ct", true},
+		{Filter{Regions: []string{"ca"}}, "us/ct", false},
+		{Filter{Search: "potency"}, "us/ct", true},
+		{Filter{Search: "RETAIL"}, "us/ct", true},
+		{Filter{Search: "lab tests"}, "us/ct", true},
+		{Filter{Search: "sales"}, "us/ct", false},
+		{Filter{Search: "US/C"}, "us/ct", true},
+		{Filter{Search: "data portal"}, "us/ct", true},
+		{Filter{Search: "cc0"}, "us/ct", true},
+		{Filter{Search: "tests"}, "ca/on", true},
+		{Filter{Regions: []string{"us"}, Search: "brands"}, "us/ct", true},
+		{Filter{Regions: []string{"ca"}, Search: "potency"}, "us/ct", false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Matches(tt.id, ct); got != tt.want {
+			t.Errorf("%+v.Matches(%q) = %v; want %v", tt.filter, tt.id, got, tt.want)
+		}
+	}
+}
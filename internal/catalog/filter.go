@@ -0,0 +1,55 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package catalog
+
+import "strings"
+
+// Filter selects dataset entries, as by `dank-mcp --list --region us`.
+// The zero Filter matches every entry.
+type Filter struct {
+	// Regions are dataset ids or jurisdictions, or prefixes of them, such
+	// as "us", "us/ct" or "US-CT"; an entry matching any of them matches.
+	Regions []string
+
+	// Search is text to find, ignoring case, in an entry's id, title,
+	// description, attribution, license, tags or tables.
+	Search string
+}
+
+// Matches returns whether the entry for dataset id is selected by f.
+func (f Filter) Matches(id string, e DatasetEntry) bool {
+	if len(f.Regions) > 0 {
+		var inRegion bool
+		for _, region := range f.Regions {
+			if hasPathPrefix(id, region, "/") || hasPathPrefix(e.Jurisdiction, region, "-") {
+				inRegion = true
+				break
+			}
+		}
+		if !inRegion {
+			return false
+		}
+	}
+	if f.Search == "" {
+		return true
+	}
+	search := strings.ToLower(f.Search)
+	fields := []string{id, e.Title, e.Description, e.Attribution, e.License}
+	fields = append(fields, e.Tags...)
+	for _, table := range e.Tables {
+		fields = append(fields, table.Name, table.Description)
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPathPrefix returns whether s is prefix, or begins with prefix and
+// then sep, ignoring case.
+func hasPathPrefix(s, prefix, sep string) bool {
+	s, prefix = strings.ToLower(s), strings.ToLower(strings.TrimSuffix(prefix, sep))
+	return prefix != "" && (s == prefix || strings.HasPrefix(s, prefix+sep))
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package catalog
+
+import "sort"
+
+// Shadowed is a dataset entry hidden by an entry with the same id in a
+// catalog of higher precedence.
+type Shadowed struct {
+	ID     string // Dataset id
+	Source string // Location of the catalog with the hidden entry
+	By     string // Location of the catalog with the entry used instead
+}
+
+// Merge merges catalogs, given in order of precedence: a dataset id listed
+// by several catalogs is taken from the first of them, and the others'
+// entries for it are returned as Shadowed, sorted by id.
+func Merge(catalogs ...Catalog) (Catalog, []Shadowed) {
+	merged := Catalog{Version: currentVersion, Datasets: make(map[string]DatasetEntry)}
+	var shadowed []Shadowed
+	for _, cat := range catalogs {
+		for id, entry := range cat.Datasets {
+			if first, ok := merged.Datasets[id]; ok {
+				shadowed = append(shadowed, Shadowed{ID: id, Source: entry.Source, By: first.Source})
+				continue
+			}
+			merged.Datasets[id] = entry
+		}
+	}
+	sort.SliceStable(shadowed, func(i, j int) bool { return shadowed[i].ID < shadowed[j].ID })
+	return merged, shadowed
+}
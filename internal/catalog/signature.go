@@ -0,0 +1,205 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/AgentDank/dank-mcp/internal/retry"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Catalogs are signed with minisign (https://jedisct1.github.io/minisign/):
+// a detached Ed25519 signature is published next to each catalog, at its
+// URL with SignatureSuffix appended, e.g. catalog.json.sig.
+
+const (
+	// SignatureSuffix is appended to a catalog's location to find its signature.
+	SignatureSuffix = ".sig"
+
+	maxSignatureSize = 64 * 1024
+
+	untrustedCommentPrefix = "untrusted comment:"
+	trustedCommentPrefix   = "trusted comment: "
+)
+
+var (
+	algEd25519   = [2]byte{'E', 'd'} // signature of the catalog itself, as by `minisign -S -l`
+	algPrehashed = [2]byte{'E', 'D'} // signature of its BLAKE2b-512 hash, minisign's default
+)
+
+// DefaultPublicKey is the base64 minisign public key that dank-data signs
+// its catalog with, trusted along with any keys given with --catalog-key.
+// It is a constant, rather than set at build time, so that every build
+// trusts the same key and changing it goes through code review. While it
+// is empty, catalogs are verified only against --catalog-key.
+const DefaultPublicKey = ""
+
+// ErrUnverified is returned, wrapped, when a catalog's signature is
+// missing or is not a valid signature by a trusted key.
+var ErrUnverified = errors.New("catalog signature not verified")
+
+// PublicKey is a minisign Ed25519 public key.
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// String returns the key's id in hex, as minisign shows it.
+func (k PublicKey) String() string {
+	return formatKeyID(k.ID)
+}
+
+func formatKeyID(id [8]byte) string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id[:]))
+}
+
+// ParsePublicKey parses a minisign public key: either its base64 encoding,
+// or the contents of a minisign .pub file.
+func ParsePublicKey(s string) (PublicKey, error) {
+	lines := signatureLines([]byte(s))
+	if len(lines) == 2 && strings.HasPrefix(lines[0], untrustedCommentPrefix) {
+		lines = lines[1:]
+	}
+	if len(lines) != 1 {
+		return PublicKey{}, errors.New("invalid public key: expected a base64 minisign key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize {
+		return PublicKey{}, errors.New("invalid public key: expected a base64 minisign key")
+	}
+	if [2]byte(raw[:2]) != algEd25519 {
+		return PublicKey{}, fmt.Errorf("invalid public key: unsupported algorithm %q", raw[:2])
+	}
+	return PublicKey{ID: [8]byte(raw[2:10]), Key: ed25519.PublicKey(raw[10:])}, nil
+}
+
+// ReadPublicKey returns the public key named by s, either the path of a
+// minisign .pub file or a key as ParsePublicKey accepts.
+func ReadPublicKey(s string) (PublicKey, error) {
+	if body, err := os.ReadFile(s); err == nil {
+		key, err := ParsePublicKey(string(body))
+		if err != nil {
+			return PublicKey{}, fmt.Errorf("%s: %w", s, err)
+		}
+		return key, nil
+	}
+	return ParsePublicKey(s)
+}
+
+// Keyring is the set of public keys trusted to sign catalogs.
+type Keyring []PublicKey
+
+// DefaultKeyring returns a keyring of DefaultPublicKey, which is empty if
+// no key is pinned.
+func DefaultKeyring() (Keyring, error) {
+	if DefaultPublicKey == "" {
+		return Keyring{}, nil
+	}
+	key, err := ParsePublicKey(DefaultPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("pinned catalog key: %w", err)
+	}
+	return Keyring{key}, nil
+}
+
+// Trusts returns whether the key with id keyID, as formatted by
+// PublicKey.String, is in k.
+func (k Keyring) Trusts(keyID string) bool {
+	for _, key := range k {
+		if key.String() == keyID {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify checks that sig, the contents of a minisign signature file, is a
+// signature of body by a key in k, with its trusted comment signed by the
+// same key. Returns the id of the key.
+func (k Keyring) Verify(body, sig []byte) (string, error) {
+	lines := signatureLines(sig)
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], untrustedCommentPrefix) || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return "", errors.New("malformed signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return "", errors.New("malformed signature")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return "", errors.New("malformed signature")
+	}
+
+	alg, keyID, signature := [2]byte(raw[:2]), [8]byte(raw[2:10]), raw[10:]
+	var key *PublicKey
+	for i := range k {
+		if k[i].ID == keyID {
+			key = &k[i]
+			break
+		}
+	}
+	if key == nil {
+		return "", fmt.Errorf("signed by untrusted key %s", formatKeyID(keyID))
+	}
+
+	message := body
+	switch alg {
+	case algEd25519:
+	case algPrehashed:
+		hash := blake2b.Sum512(body)
+		message = hash[:]
+	default:
+		return "", fmt.Errorf("unsupported signature algorithm %q", alg[:])
+	}
+	if !ed25519.Verify(key.Key, message, signature) {
+		return "", fmt.Errorf("invalid signature by key %s", key)
+	}
+	trustedComment := strings.TrimPrefix(lines[2], trustedCommentPrefix)
+	if !ed25519.Verify(key.Key, append(bytes.Clone(signature), trustedComment...), globalSig) {
+		return "", fmt.Errorf("invalid trusted comment signature by key %s", key)
+	}
+	return key.String(), nil
+}
+
+// signatureLines returns the non-empty lines of a minisign file, trimmed.
+func signatureLines(body []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(body), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// verifySignature fetches the signature of the catalog at location and
+// verifies it is a signature of body by a key in keys. Returns the id of
+// the key.
+func verifySignature(ctx context.Context, location string, client *http.Client, policy retry.Policy, keys Keyring, body []byte) (string, error) {
+	sigURL := location + SignatureSuffix
+	var sig []byte
+	var err error
+	if strings.HasPrefix(location, "file://") {
+		sig, _, err = readFileIfModified(sigURL, Validators{}, maxSignatureSize)
+	} else {
+		sig, _, err = getIfModified(ctx, sigURL, client, policy, Validators{}, maxSignatureSize)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrUnverified, err)
+	}
+	keyID, err := keys.Verify(body, sig)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", ErrUnverified, sigURL, err)
+	}
+	return keyID, nil
+}
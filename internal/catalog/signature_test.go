@@ -0,0 +1,193 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AgentDank/dank-mcp/internal/retry"
+	"golang.org/x/crypto/blake2b"
+)
+
+// testKey is a minisign key pair.
+type testKey struct {
+	public  PublicKey
+	private ed25519.PrivateKey
+}
+
+func newTestKey(t *testing.T) testKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var id [8]byte
+	rand.Read(id[:])
+	return testKey{public: PublicKey{ID: id, Key: pub}, private: priv}
+}
+
+// encoded returns the key's public key as minisign encodes it.
+func (k testKey) encoded() string {
+	raw := append(append([]byte("Ed"), k.public.ID[:]...), k.public.Key...)
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// sign returns a minisign signature file of body, with alg "Ed" or "ED".
+func (k testKey) sign(body []byte, alg string) []byte {
+	message := body
+	if alg == "ED" {
+		hash := blake2b.Sum512(body)
+		message = hash[:]
+	}
+	signature := ed25519.Sign(k.private, message)
+	trustedComment := "timestamp:1776556800\tfile:catalog.json"
+	globalSig := ed25519.Sign(k.private, append(append([]byte{}, signature...), trustedComment...))
+	raw := append(append([]byte(alg), k.public.ID[:]...), signature...)
+	return []byte(fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw), trustedComment, base64.StdEncoding.EncodeToString(globalSig)))
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key := newTestKey(t)
+	pubFile := "untrusted comment: minisign public key " + key.public.String() + "\n" + key.encoded() + "\n"
+	for _, s := range []string{key.encoded(), pubFile} {
+		got, err := ParsePublicKey(s)
+		if err != nil {
+			t.Fatalf("ParsePublicKey(%q): %v", s, err)
+		}
+		if got.ID != key.public.ID || !got.Key.Equal(key.public.Key) {
+			t.Errorf("ParsePublicKey(%q) = %v", s, got)
+		}
+	}
+	for _, s := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("Ed short"))} {
+		if _, err := ParsePublicKey(s); err == nil {
+			t.Errorf("ParsePublicKey(%q): expected error", s)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "dank.pub")
+	if err := os.WriteFile(path, []byte(pubFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadPublicKey(path); err != nil || got.ID != key.public.ID {
+		t.Errorf("ReadPublicKey(%q) = %v, %v", path, got, err)
+	}
+}
+
+func TestDefaultKeyring(t *testing.T) {
+	keys, err := DefaultKeyring()
+	if err != nil {
+		t.Fatalf("DefaultKeyring: %v", err)
+	}
+	if want := min(len(DefaultPublicKey), 1); len(keys) != want {
+		t.Errorf("DefaultKeyring() has %d keys, want %d", len(keys), want)
+	}
+}
+
+func TestKeyringVerify(t *testing.T) {
+	key, other := newTestKey(t), newTestKey(t)
+	keys := Keyring{other.public, key.public}
+	body := []byte(validCatalog)
+
+	for _, alg := range []string{"Ed", "ED"} {
+		keyID, err := keys.Verify(body, key.sign(body, alg))
+		if err != nil {
+			t.Fatalf("Verify %s: %v", alg, err)
+		}
+		if keyID != key.public.String() || !keys.Trusts(keyID) {
+			t.Errorf("Verify %s: key id %q; want %q", alg, keyID, key.public)
+		}
+	}
+
+	tamperedComment := bytes.Replace(key.sign(body, "ED"), []byte("timestamp:1776556800"), []byte("timestamp:1776556801"), 1)
+	tests := map[string]struct {
+		keys Keyring
+		body []byte
+		sig  []byte
+	}{
+		"tampered body":    {keys, []byte(validCatalog + " "), key.sign(body, "ED")},
+		"untrusted key":    {Keyring{other.public}, body, key.sign(body, "ED")},
+		"tampered comment": {keys, body, tamperedComment},
+		"malformed":        {keys, body, []byte("untrusted comment: x\nnot a signature\n")},
+		"empty keyring":    {Keyring{}, body, key.sign(body, "ED")},
+	}
+	for name, tt := range tests {
+		if _, err := tt.keys.Verify(tt.body, tt.sig); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestFetchIfModified_Signed(t *testing.T) {
+	key := newTestKey(t)
+	sig := key.sign([]byte(validCatalog), "ED")
+	var signed bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/catalog.json":
+			w.Write([]byte(validCatalog))
+		case r.URL.Path == "/catalog.json.sig" && signed:
+			w.Write(sig)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	catalogURL := srv.URL + "/catalog.json"
+	fetchSigned := func(keys Keyring) (Catalog, error) {
+		cat, _, err := FetchIfModified(context.Background(), catalogURL, srv.Client(), retry.Policy{}, keys, Validators{})
+		return cat, err
+	}
+
+	if _, err := fetchSigned(Keyring{key.public}); !errors.Is(err, ErrUnverified) {
+		t.Errorf("unsigned catalog: expected ErrUnverified, got %v", err)
+	}
+	if _, err := fetchSigned(nil); err != nil {
+		t.Errorf("unsigned catalog without keys: %v", err)
+	}
+
+	signed = true
+	cat, err := fetchSigned(Keyring{key.public})
+	if err != nil {
+		t.Fatalf("signed catalog: %v", err)
+	}
+	if got := cat.Datasets["us/ct"].KeyID; got != key.public.String() {
+		t.Errorf("KeyID = %q; want %q", got, key.public)
+	}
+	if _, err := fetchSigned(Keyring{newTestKey(t).public}); !errors.Is(err, ErrUnverified) {
+		t.Errorf("catalog signed by another key: expected ErrUnverified, got %v", err)
+	}
+}
+
+func TestFetchIfModified_SignedFile(t *testing.T) {
+	key := newTestKey(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "catalog.json"), []byte(validCatalog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	location, err := Location(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := Keyring{key.public}
+	if _, _, err := FetchIfModified(context.Background(), location, nil, retry.Policy{}, keys, Validators{}); !errors.Is(err, ErrUnverified) {
+		t.Errorf("unsigned catalog: expected ErrUnverified, got %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "catalog.json.sig"), key.sign([]byte(validCatalog), "ED"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := FetchIfModified(context.Background(), location, nil, retry.Policy{}, keys, Validators{}); err != nil {
+		t.Errorf("signed catalog: %v", err)
+	}
+}
@@ -3,12 +3,16 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	_ "embed"
 	"fmt"
+	"path/filepath"
+	"strings"
 
-	// Import the DuckDB driver
-	_ "github.com/duckdb/duckdb-go/v2"
+	// Import the DuckDB driver, which also registers it with database/sql
+	"github.com/duckdb/duckdb-go/v2"
 )
 
 //go:embed duckdb_safe.sql
@@ -16,12 +20,180 @@ var DuckdbSafeMigration string
 
 ///////////////////////////////////////////////////////////////////////////////
 
-// RunSafeMode locks the database down with the DuckdbSafeMigration.
+// Hardening is the resource limits applied by RunSafeMode.
+// Empty or zero fields keep DuckDB's defaults.
+type Hardening struct {
+	MemoryLimit          string // Maximum memory DuckDB may use, e.g. "2GB"
+	Threads              int    // Number of threads DuckDB may use
+	MaxTempDirectorySize string // Maximum disk DuckDB may spill to, e.g. "4GB"
+}
+
+// DefaultHardening is the default Hardening profile.
+var DefaultHardening = Hardening{
+	MemoryLimit:          "2GB",
+	MaxTempDirectorySize: "4GB",
+}
+
+// Validate checks the profile, returning nil on success.
+func (h Hardening) Validate() error {
+	if h.Threads < 0 {
+		return fmt.Errorf("threads must not be negative")
+	}
+	return nil
+}
+
+// statements returns the SET statements applying the profile.
+func (h Hardening) statements() []string {
+	var stmts []string
+	if h.MemoryLimit != "" {
+		stmts = append(stmts, "SET memory_limit = "+QuoteString(h.MemoryLimit))
+	}
+	if h.Threads > 0 {
+		stmts = append(stmts, fmt.Sprintf("SET threads = %d", h.Threads))
+	}
+	if h.MaxTempDirectorySize != "" {
+		stmts = append(stmts, "SET max_temp_directory_size = "+QuoteString(h.MaxTempDirectorySize))
+	}
+	return stmts
+}
+
+// RunSafeMode locks the database down with the DuckdbSafeMigration, applies
+// the hardening profile, and then locks the configuration so that no
+// setting can be changed afterwards.
 // Returns an error, if any
-func RunSafeMode(conn *sql.DB) error {
+func RunSafeMode(conn *sql.DB, hardening Hardening) error {
+	if err := hardening.Validate(); err != nil {
+		return fmt.Errorf("invalid hardening profile: %w", err)
+	}
 	_, err := conn.Exec(DuckdbSafeMigration)
 	if err != nil {
 		return fmt.Errorf("failed to run safe mode migration: %w", err)
 	}
+	for _, stmt := range hardening.statements() {
+		if _, err := conn.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to apply hardening %q: %w", stmt, err)
+		}
+	}
+	if _, err := conn.Exec("SET lock_configuration = true"); err != nil {
+		return fmt.Errorf("failed to lock configuration: %w", err)
+	}
 	return nil
 }
+
+// Attachment is a DuckDB file attached read-only under an alias, so its
+// tables can be queried as alias.table.
+type Attachment struct {
+	Path  string // DuckDB file
+	Alias string // Database name to attach it as
+}
+
+// reservedAliases are the database names DuckDB reserves, or has already
+// attached to the in-memory database that OpenReadOnly attaches to.
+var reservedAliases = []string{"main", "memory", "system", "temp"}
+
+// FileAlias returns the name to attach the DuckDB file at path as: its
+// base name without extension, as DuckDB names a file it opens, with "_db"
+// appended if that is a name DuckDB reserves, e.g. "memory_db".
+func FileAlias(path string) string {
+	alias := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	for _, reserved := range reservedAliases {
+		if strings.EqualFold(alias, reserved) {
+			return alias + "_db"
+		}
+	}
+	return alias
+}
+
+// OpenReadOnly opens an empty in-memory DuckDB with each attachment
+// attached read-only, and locks it down with RunSafeMode. Unqualified names
+// resolve in the database named defaultAlias, if it is not empty.
+//
+// DuckDB files opened directly are shared by path within the process, so
+// attaching them instead lets a file that has been replaced on disk be
+// opened anew while the previous connection is still serving.
+func OpenReadOnly(attachments []Attachment, defaultAlias string, hardening Hardening) (*sql.DB, error) {
+	var initSQL []string
+	aliases := make(map[string]bool, len(attachments))
+	for _, a := range attachments {
+		if aliases[a.Alias] {
+			return nil, fmt.Errorf("cannot attach %s: database name %q is already in use", a.Path, a.Alias)
+		}
+		aliases[a.Alias] = true
+		// Attachments are shared by all connections, so only the first attaches
+		initSQL = append(initSQL, fmt.Sprintf("ATTACH IF NOT EXISTS %s AS %s (READ_ONLY)", QuoteString(a.Path), QuoteIdentifier(a.Alias)))
+	}
+	if defaultAlias != "" {
+		// USE is per connection
+		initSQL = append(initSQL, "USE "+QuoteIdentifier(defaultAlias))
+	}
+	connector, err := duckdb.NewConnector("", func(execer driver.ExecerContext) error {
+		for _, stmt := range initSQL {
+			if _, err := execer.ExecContext(context.Background(), stmt, nil); err != nil {
+				return fmt.Errorf("failed to initialize connection: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open duckdb: %w", err)
+	}
+	conn := sql.OpenDB(connector)
+	// Attach before safe mode disables file access
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := RunSafeMode(conn, hardening); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// QueryParamNames prepares query on conn and returns the names of its
+// parameters in order, e.g. "brand" for $brand or "1" for $1 or ?.
+// Returns an error, if any, including when the query fails to prepare.
+func QueryParamNames(ctx context.Context, conn *sql.DB, query string) ([]string, error) {
+	sqlConn, err := conn.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer sqlConn.Close()
+
+	var names []string
+	err = sqlConn.Raw(func(driverConn any) error {
+		duckConn, ok := driverConn.(*duckdb.Conn)
+		if !ok {
+			return fmt.Errorf("not a DuckDB connection: %T", driverConn)
+		}
+		stmt, err := duckConn.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		duckStmt := stmt.(*duckdb.Stmt)
+		for i := 1; i <= duckStmt.NumInput(); i++ {
+			name, err := duckStmt.ParamName(i)
+			if err != nil {
+				return err
+			}
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %w", err)
+	}
+	return names, nil
+}
+
+// QuoteIdentifier quotes name as a DuckDB identifier, so it can be safely
+// spliced into SQL where parameters are not allowed (e.g. table names).
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteString quotes s as a DuckDB string literal.
+func QuoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
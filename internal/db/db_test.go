@@ -0,0 +1,137 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunSafeMode(t *testing.T) {
+	conn := openTestDB(t)
+	hardening := Hardening{MemoryLimit: "512MB", Threads: 2, MaxTempDirectorySize: "1GB"}
+	if err := RunSafeMode(conn, hardening); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := map[string]string{
+		"enable_external_access":    "false",
+		"autoload_known_extensions": "false",
+		"lock_configuration":        "true",
+		"threads":                   "2",
+		"memory_limit":              "488.2 MiB",
+		"max_temp_directory_size":   "953.6 MiB",
+	}
+	for name, want := range settings {
+		var got string
+		if err := conn.QueryRow("SELECT current_setting(?)::VARCHAR", name).Scan(&got); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("%s = %q; want %q", name, got, want)
+		}
+	}
+
+	// The configuration is locked against later changes
+	for _, stmt := range []string{
+		"SET enable_external_access = true",
+		"SET memory_limit = '64GB'",
+		"SET threads = 64",
+		"RESET memory_limit",
+		"SET lock_configuration = false",
+	} {
+		if _, err := conn.Exec(stmt); err == nil {
+			t.Errorf("%q: expected error", stmt)
+		}
+	}
+}
+
+func TestRunSafeMode_Invalid(t *testing.T) {
+	if err := RunSafeMode(openTestDB(t), Hardening{Threads: -1}); err == nil {
+		t.Error("expected error for negative threads")
+	}
+	if err := RunSafeMode(openTestDB(t), Hardening{MemoryLimit: "lots"}); err == nil {
+		t.Error("expected error for bad memory limit")
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"ct", "ma"} {
+		src, err := sql.Open("duckdb", filepath.Join(dir, name+".duckdb"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := src.Exec("CREATE TABLE brands (brand_name VARCHAR); INSERT INTO brands VALUES (?)", name); err != nil {
+			t.Fatal(err)
+		}
+		src.Close()
+	}
+	attachments := []Attachment{
+		{Path: filepath.Join(dir, "ct.duckdb"), Alias: "us_ct"},
+		{Path: filepath.Join(dir, "ma.duckdb"), Alias: "us_ma"},
+	}
+
+	conn, err := OpenReadOnly(attachments, "us_ct", DefaultHardening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetMaxIdleConns(0) // every query initializes a new connection
+
+	for i := 0; i < 2; i++ {
+		var ct, ma string
+		if err := conn.QueryRow("SELECT (SELECT brand_name FROM brands), (SELECT brand_name FROM us_ma.brands)").Scan(&ct, &ma); err != nil || ct != "ct" || ma != "ma" {
+			t.Errorf("query %d: %q, %q, %v", i, ct, ma, err)
+		}
+	}
+	if _, err := conn.Exec("INSERT INTO brands VALUES ('Beta')"); err == nil {
+		t.Error("expected error writing to read-only attachment")
+	}
+	// Safe mode blocks attaching more files
+	other := filepath.Join(t.TempDir(), "other.duckdb")
+	if _, err := conn.Exec("ATTACH " + QuoteString(other) + " AS other"); err == nil {
+		t.Error("expected error attaching after safe mode")
+	}
+
+	if _, err := OpenReadOnly([]Attachment{{Path: filepath.Join(dir, "missing.duckdb"), Alias: "missing"}}, "", DefaultHardening); err == nil {
+		t.Error("expected error attaching a missing file")
+	}
+}
+
+func TestFileAlias(t *testing.T) {
+	tests := map[string]string{
+		"/data/ct.duckdb":     "ct",
+		"/data/memory.duckdb": "memory_db",
+		"/data/Temp.db":       "Temp_db",
+		"system":              "system_db",
+		"main.duckdb":         "main_db",
+		"mainline.duckdb":     "mainline",
+	}
+	for path, want := range tests {
+		if got := FileAlias(path); got != want {
+			t.Errorf("FileAlias(%q) = %q; want %q", path, got, want)
+		}
+	}
+
+	// A reserved name attaches under its alias, as the baseline opened it
+	path := filepath.Join(t.TempDir(), "memory.duckdb")
+	src, err := sql.Open("duckdb", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.Exec("CREATE TABLE brands (brand_name VARCHAR); INSERT INTO brands VALUES ('ct')"); err != nil {
+		t.Fatal(err)
+	}
+	src.Close()
+	conn, err := OpenReadOnly([]Attachment{{Path: path, Alias: FileAlias(path)}}, FileAlias(path), DefaultHardening)
+	if err != nil {
+		t.Fatalf("OpenReadOnly memory.duckdb: %v", err)
+	}
+	defer conn.Close()
+	var brand string
+	if err := conn.QueryRow("SELECT brand_name FROM brands").Scan(&brand); err != nil || brand != "ct" {
+		t.Errorf("query: %q, %v", brand, err)
+	}
+}
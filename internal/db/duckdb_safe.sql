@@ -1,3 +1,5 @@
 -- Hardens our DuckDB database from SQL injection attacks
 
-SET enable_external_access=false
+SET enable_external_access=false;
+SET autoinstall_known_extensions=false;
+SET autoload_known_extensions=false;
@@ -3,84 +3,350 @@
 package db
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 )
 
-// RowsToCSV converts sql.Rows rows to a CSV string.
-func RowsToCSV(rows *sql.Rows) (string, error) {
+// Limits bounds the size of an encoded result. Zero fields mean no limit.
+type Limits struct {
+	MaxRows  int // Maximum number of data rows
+	MaxBytes int // Maximum size of the encoded result, in bytes
+}
+
+// ResultInfo describes an encoded result.
+type ResultInfo struct {
+	Rows      int    // Number of data rows encoded
+	Truncated bool   // True if more rows exist than were encoded
+	Reason    string // If Truncated, the limit reached: "max_rows" or "max_bytes"
+}
+
+// RowEncoder encodes a result set into a buffer, one row at a time.
+// WriteHeader is called once, then WriteRow for each row, then WriteFooter.
+// The footer must not depend on the rows written, so that its size can be
+// reserved when enforcing Limits.
+type RowEncoder interface {
+	WriteHeader() error
+	WriteRow(values []interface{}) error
+	WriteFooter() error
+}
+
+// Format is a named output format for query results.
+type Format struct {
+	Name       string                                                        // Name of the format, e.g. "csv"
+	MimeType   string                                                        // MIME type of the encoded result
+	NewEncoder func(buf *bytes.Buffer, columns []Column) (RowEncoder, error) // Creates an encoder writing to buf
+}
+
+// Formats are the supported output formats, keyed by name.
+// Add to it to plug in another format.
+var Formats = map[string]Format{
+	"csv":      {Name: "csv", MimeType: "text/csv", NewEncoder: newCSVEncoder},
+	"json":     {Name: "json", MimeType: "application/json", NewEncoder: newJSONEncoder},
+	"ndjson":   {Name: "ndjson", MimeType: "application/x-ndjson", NewEncoder: newNDJSONEncoder},
+	"markdown": {Name: "markdown", MimeType: "text/markdown", NewEncoder: newMarkdownEncoder},
+}
+
+// FormatNames returns the names of the supported formats, sorted.
+func FormatNames() []string {
+	names := make([]string, 0, len(Formats))
+	for name := range Formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FormatForMimeType returns the Format that encodes the given MIME type.
+func FormatForMimeType(mimeType string) (Format, bool) {
+	for _, f := range Formats {
+		if f.MimeType == mimeType {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+// errStopScan is returned by a scanRows callback to stop scanning early.
+var errStopScan = errors.New("stop scan")
+
+// EncodeRows encodes sql.Rows rows in the given format, rendering values
+// with opts and stopping once the next row would exceed limits. The header
+// and footer are always included. The returned ResultInfo reports how many
+// rows were encoded and whether the result was truncated.
+func EncodeRows(rows *sql.Rows, format Format, limits Limits, opts ValueOptions) (string, ResultInfo, error) {
+	var info ResultInfo
 	if rows == nil {
-		return "", fmt.Errorf("rows is nil")
+		return "", info, fmt.Errorf("rows is nil")
 	}
-	// Get column names
-	columns, err := rows.Columns()
+	columns, err := NewColumns(rows, opts)
 	if err != nil {
-		return "", fmt.Errorf("error getting column names: %w", err)
+		return "", info, err
 	}
 
-	// Create CSV writer and string.Builder
-	var sb strings.Builder
-	writer := csv.NewWriter(&sb)
+	// Measure the footer, so rows leave room for it
+	var footer bytes.Buffer
+	footerEncoder, err := format.NewEncoder(&footer, columns)
+	if err != nil {
+		return "", info, err
+	}
+	if err := footerEncoder.WriteFooter(); err != nil {
+		return "", info, fmt.Errorf("error writing footer: %w", err)
+	}
 
-	// Write header row
-	if err := writer.Write(columns); err != nil {
-		return "", fmt.Errorf("error writing header row: %w", err)
+	var buf bytes.Buffer
+	encoder, err := format.NewEncoder(&buf, columns)
+	if err != nil {
+		return "", info, err
+	}
+	if err := encoder.WriteHeader(); err != nil {
+		return "", info, fmt.Errorf("error writing header: %w", err)
 	}
 
-	// Create a slice of interface{} to hold each row's values
-	values := make([]interface{}, len(columns))
-	// Create a slice of pointers to the values
-	valuePtrs := make([]interface{}, len(columns))
-	for i := range columns {
-		valuePtrs[i] = &values[i]
+	err = scanRows(rows, len(columns), func(values []interface{}) error {
+		if limits.MaxRows > 0 && info.Rows >= limits.MaxRows {
+			info.Truncated, info.Reason = true, "max_rows"
+			return errStopScan
+		}
+		// Write the row, dropping it again if it overflows
+		prevLen := buf.Len()
+		if err := encoder.WriteRow(values); err != nil {
+			return fmt.Errorf("error writing row: %w", err)
+		}
+		if limits.MaxBytes > 0 && buf.Len()+footer.Len() > limits.MaxBytes {
+			buf.Truncate(prevLen)
+			info.Truncated, info.Reason = true, "max_bytes"
+			return errStopScan
+		}
+		info.Rows++
+		return nil
+	})
+	if err != nil {
+		return "", info, err
 	}
 
-	// Iterate through rows
-	for rows.Next() {
-		// Scan the row into the valuePtrs slice
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return "", fmt.Errorf("error scanning row: %w", err)
+	if err := encoder.WriteFooter(); err != nil {
+		return "", info, fmt.Errorf("error writing footer: %w", err)
+	}
+	return buf.String(), info, nil
+}
+
+// RowsToCSV converts sql.Rows rows to a CSV string.
+func RowsToCSV(rows *sql.Rows) (string, error) {
+	csvData, _, err := EncodeRows(rows, Formats["csv"], Limits{}, ValueOptions{})
+	return csvData, err
+}
+
+// RowsToJSON converts sql.Rows rows to a JSON array of objects, one per row,
+// with keys in column order.
+func RowsToJSON(rows *sql.Rows) (string, error) {
+	jsonData, _, err := EncodeRows(rows, Formats["json"], Limits{}, ValueOptions{})
+	return jsonData, err
+}
+
+// RowsToMarkdown converts sql.Rows rows to a GitHub-flavored Markdown table.
+func RowsToMarkdown(rows *sql.Rows) (string, error) {
+	markdown, _, err := EncodeRows(rows, Formats["markdown"], Limits{}, ValueOptions{})
+	return markdown, err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+// csvEncoder encodes rows as CSV with a header row.
+type csvEncoder struct {
+	writer  *csv.Writer
+	columns []Column
+	strings []string // reused per row
+}
+
+func newCSVEncoder(buf *bytes.Buffer, columns []Column) (RowEncoder, error) {
+	return &csvEncoder{writer: csv.NewWriter(buf), columns: columns, strings: make([]string, len(columns))}, nil
+}
+
+func (e *csvEncoder) WriteHeader() error {
+	return e.write(ColumnNames(e.columns))
+}
+
+func (e *csvEncoder) WriteRow(values []interface{}) error {
+	for i, val := range values {
+		e.strings[i] = e.columns[i].Text(val)
+	}
+	return e.write(e.strings)
+}
+
+func (e *csvEncoder) WriteFooter() error {
+	return nil
+}
+
+// write writes one record and flushes it, so the buffer length is current.
+func (e *csvEncoder) write(record []string) error {
+	if err := e.writer.Write(record); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// jsonEncoder encodes rows as a JSON array of objects, or as newline
+// delimited JSON objects if ndjson is set.
+type jsonEncoder struct {
+	buf     *bytes.Buffer
+	columns []Column
+	keys    [][]byte // pre-encoded column names
+	ndjson  bool
+	rows    int
+}
+
+func newJSONEncoder(buf *bytes.Buffer, columns []Column) (RowEncoder, error) {
+	return makeJSONEncoder(buf, columns, false)
+}
+
+func newNDJSONEncoder(buf *bytes.Buffer, columns []Column) (RowEncoder, error) {
+	return makeJSONEncoder(buf, columns, true)
+}
+
+func makeJSONEncoder(buf *bytes.Buffer, columns []Column, ndjson bool) (RowEncoder, error) {
+	// Pre-encode the keys, since they are the same for every row
+	keys := make([][]byte, len(columns))
+	for i, col := range columns {
+		var err error
+		if keys[i], err = json.Marshal(col.Name); err != nil {
+			return nil, fmt.Errorf("error encoding column name: %w", err)
 		}
+	}
+	return &jsonEncoder{buf: buf, columns: columns, keys: keys, ndjson: ndjson}, nil
+}
 
-		// Convert each value to string
-		stringValues := make([]string, len(columns))
-		for i, val := range values {
-			stringValues[i] = toString(val)
+func (e *jsonEncoder) WriteHeader() error {
+	if !e.ndjson {
+		e.buf.WriteByte('[')
+	}
+	return nil
+}
+
+func (e *jsonEncoder) WriteRow(values []interface{}) error {
+	// Encode the values first, so a failure leaves the buffer intact
+	encoded := make([][]byte, len(values))
+	for i, val := range values {
+		var err error
+		if encoded[i], err = json.Marshal(e.columns[i].JSONValue(val)); err != nil {
+			return fmt.Errorf("error encoding column %s: %w", e.keys[i], err)
 		}
+	}
 
-		// Write the row to the CSV
-		if err := writer.Write(stringValues); err != nil {
-			return "", fmt.Errorf("error writing row: %w", err)
+	if e.rows > 0 && !e.ndjson {
+		e.buf.WriteByte(',')
+	}
+	e.rows++
+	e.buf.WriteByte('{')
+	for i, valueJSON := range encoded {
+		if i > 0 {
+			e.buf.WriteByte(',')
 		}
+		e.buf.Write(e.keys[i])
+		e.buf.WriteByte(':')
+		e.buf.Write(valueJSON)
 	}
+	e.buf.WriteByte('}')
+	if e.ndjson {
+		e.buf.WriteByte('\n')
+	}
+	return nil
+}
 
-	// Check for errors from iterating over rows
-	if err := rows.Err(); err != nil {
-		return "", fmt.Errorf("error iterating through rows: %w", err)
+func (e *jsonEncoder) WriteFooter() error {
+	if !e.ndjson {
+		e.buf.WriteByte(']')
 	}
+	return nil
+}
 
-	// Make sure to flush to write any buffered data to the string builder
-	writer.Flush()
+// markdownEncoder encodes rows as a GitHub-flavored Markdown table.
+type markdownEncoder struct {
+	buf     *bytes.Buffer
+	columns []Column
+	cells   []string // reused per row
+}
 
-	if err := writer.Error(); err != nil {
-		return "", fmt.Errorf("error flushing CSV writer: %w", err)
+func newMarkdownEncoder(buf *bytes.Buffer, columns []Column) (RowEncoder, error) {
+	return &markdownEncoder{buf: buf, columns: columns, cells: make([]string, len(columns))}, nil
+}
+
+func (e *markdownEncoder) WriteHeader() error {
+	writeMarkdownRow(e.buf, ColumnNames(e.columns))
+	e.buf.WriteByte('|')
+	for range e.columns {
+		e.buf.WriteString(" --- |")
 	}
+	e.buf.WriteByte('\n')
+	return nil
+}
 
-	return sb.String(), nil
+func (e *markdownEncoder) WriteRow(values []interface{}) error {
+	for i, val := range values {
+		e.cells[i] = e.columns[i].Text(val)
+	}
+	writeMarkdownRow(e.buf, e.cells)
+	return nil
 }
 
-// Helper function to convert various types to string
-func toString(value interface{}) string {
-	if value == nil {
-		return ""
+func (e *markdownEncoder) WriteFooter() error {
+	return nil
+}
+
+// writeMarkdownRow writes cells as one Markdown table row, escaping pipes
+// and flattening newlines so a cell cannot break the table layout.
+func writeMarkdownRow(buf *bytes.Buffer, cells []string) {
+	buf.WriteByte('|')
+	for _, cell := range cells {
+		cell = strings.ReplaceAll(cell, `|`, `\|`)
+		cell = strings.ReplaceAll(cell, "\r\n", " ")
+		cell = strings.ReplaceAll(cell, "\n", " ")
+		buf.WriteByte(' ')
+		buf.WriteString(cell)
+		buf.WriteString(" |")
+	}
+	buf.WriteByte('\n')
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+// scanRows scans every row of rows into a reused slice of numColumns values
+// and passes it to fn, until fn returns errStopScan. Returns the first other
+// error from scanning, fn, or iteration.
+func scanRows(rows *sql.Rows, numColumns int, fn func(values []interface{}) error) error {
+	// Create a slice of interface{} to hold each row's values
+	values := make([]interface{}, numColumns)
+	// Create a slice of pointers to the values
+	valuePtrs := make([]interface{}, numColumns)
+	for i := range values {
+		valuePtrs[i] = &values[i]
 	}
 
-	switch v := value.(type) {
-	case []byte:
-		return string(v)
-	default:
-		return fmt.Sprintf("%v", v)
+	// Iterate through rows
+	for rows.Next() {
+		// Scan the row into the valuePtrs slice
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return fmt.Errorf("error scanning row: %w", err)
+		}
+		if err := fn(values); err != nil {
+			if errors.Is(err, errStopScan) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	// Check for errors from iterating over rows
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating through rows: %w", err)
 	}
+	return nil
 }
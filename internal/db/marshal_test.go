@@ -0,0 +1,117 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package db
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestEncodeRows_Limits(t *testing.T) {
+	conn := openTestDB(t)
+
+	// Each data row "n\n" is 2 bytes; the header "n\n" is 2 bytes
+	cases := []struct {
+		name   string
+		limits Limits
+		want   string
+		info   ResultInfo
+	}{
+		{"unlimited", Limits{}, "n\n1\n2\n3\n", ResultInfo{Rows: 3}},
+		{"rows under", Limits{MaxRows: 5}, "n\n1\n2\n3\n", ResultInfo{Rows: 3}},
+		{"rows exact", Limits{MaxRows: 3}, "n\n1\n2\n3\n", ResultInfo{Rows: 3}},
+		{"rows over", Limits{MaxRows: 2}, "n\n1\n2\n", ResultInfo{Rows: 2, Truncated: true, Reason: "max_rows"}},
+		{"bytes exact", Limits{MaxBytes: 8}, "n\n1\n2\n3\n", ResultInfo{Rows: 3}},
+		{"bytes over", Limits{MaxBytes: 7}, "n\n1\n2\n", ResultInfo{Rows: 2, Truncated: true, Reason: "max_bytes"}},
+		{"header only", Limits{MaxBytes: 1}, "n\n", ResultInfo{Truncated: true, Reason: "max_bytes"}},
+	}
+	for _, c := range cases {
+		rows, err := conn.Query("SELECT range + 1 AS n FROM range(3)")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, info, err := EncodeRows(rows, Formats["csv"], c.limits, ValueOptions{})
+		rows.Close()
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: got %q; want %q", c.name, got, c.want)
+		}
+		if info != c.info {
+			t.Errorf("%s: info = %+v; want %+v", c.name, info, c.info)
+		}
+	}
+}
+
+func TestEncodeRows_Formats(t *testing.T) {
+	conn := openTestDB(t)
+	const query = `SELECT * FROM (VALUES ('Alpha', 22.5::DOUBLE, true), ('Be|ta', NULL, false)) AS t(brand, thc, "in stock") ORDER BY brand`
+
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{"csv", "brand,thc,in stock\nAlpha,22.5,true\nBe|ta,,false\n"},
+		{"json", `[{"brand":"Alpha","thc":22.5,"in stock":true},{"brand":"Be|ta","thc":null,"in stock":false}]`},
+		{"ndjson", "{\"brand\":\"Alpha\",\"thc\":22.5,\"in stock\":true}\n{\"brand\":\"Be|ta\",\"thc\":null,\"in stock\":false}\n"},
+		{"markdown", "| brand | thc | in stock |\n| --- | --- | --- |\n| Alpha | 22.5 | true |\n| Be\\|ta |  | false |\n"},
+	}
+	for _, c := range cases {
+		rows, err := conn.Query(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, info, err := EncodeRows(rows, Formats[c.format], Limits{}, ValueOptions{})
+		rows.Close()
+		if err != nil {
+			t.Errorf("%s: %v", c.format, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: got %q; want %q", c.format, got, c.want)
+		}
+		if info.Rows != 2 || info.Truncated {
+			t.Errorf("%s: info = %+v", c.format, info)
+		}
+	}
+}
+
+func TestEncodeRows_JSONByteLimitKeepsValidJSON(t *testing.T) {
+	conn := openTestDB(t)
+	rows, err := conn.Query("SELECT range AS n FROM range(3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	// The complete result is 25 bytes; with 24, the third row does not fit
+	// alongside the closing ]
+	got, info, err := EncodeRows(rows, Formats["json"], Limits{MaxBytes: 24}, ValueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != `[{"n":0},{"n":1}]` || info.Rows != 2 || info.Reason != "max_bytes" {
+		t.Errorf("got %q, info %+v", got, info)
+	}
+}
+
+func TestFormatForMimeType(t *testing.T) {
+	if f, ok := FormatForMimeType("application/x-ndjson"); !ok || f.Name != "ndjson" {
+		t.Errorf("ndjson: %+v, %v", f, ok)
+	}
+	if _, ok := FormatForMimeType("text/plain"); ok {
+		t.Error("text/plain should not have a format")
+	}
+}
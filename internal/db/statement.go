@@ -0,0 +1,102 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/duckdb/duckdb-go/v2"
+)
+
+// ErrStatementNotAllowed is wrapped by the errors CheckReadOnlyQuery returns
+// for statements it rejects.
+var ErrStatementNotAllowed = errors.New("statement not allowed")
+
+// explainPattern matches an EXPLAIN statement, capturing the statement it explains.
+var explainPattern = regexp.MustCompile(`(?is)^\s*EXPLAIN(?:\s+ANALY[SZ]E)?\s+(.*)$`)
+
+// statementTypeNames names the statement types CheckReadOnlyQuery rejects.
+var statementTypeNames = map[duckdb.StmtType]string{
+	duckdb.STATEMENT_TYPE_INSERT:       "INSERT",
+	duckdb.STATEMENT_TYPE_UPDATE:       "UPDATE",
+	duckdb.STATEMENT_TYPE_DELETE:       "DELETE",
+	duckdb.STATEMENT_TYPE_PREPARE:      "PREPARE",
+	duckdb.STATEMENT_TYPE_CREATE:       "CREATE",
+	duckdb.STATEMENT_TYPE_EXECUTE:      "EXECUTE",
+	duckdb.STATEMENT_TYPE_ALTER:        "ALTER",
+	duckdb.STATEMENT_TYPE_TRANSACTION:  "transaction",
+	duckdb.STATEMENT_TYPE_COPY:         "COPY",
+	duckdb.STATEMENT_TYPE_ANALYZE:      "ANALYZE",
+	duckdb.STATEMENT_TYPE_VARIABLE_SET: "SET VARIABLE",
+	duckdb.STATEMENT_TYPE_CREATE_FUNC:  "CREATE FUNCTION",
+	duckdb.STATEMENT_TYPE_DROP:         "DROP",
+	duckdb.STATEMENT_TYPE_EXPORT:       "EXPORT",
+	duckdb.STATEMENT_TYPE_PRAGMA:       "PRAGMA",
+	duckdb.STATEMENT_TYPE_VACUUM:       "VACUUM",
+	duckdb.STATEMENT_TYPE_CALL:         "CALL",
+	duckdb.STATEMENT_TYPE_SET:          "SET/RESET",
+	duckdb.STATEMENT_TYPE_LOAD:         "INSTALL/LOAD",
+	duckdb.STATEMENT_TYPE_EXTENSION:    "extension",
+	duckdb.STATEMENT_TYPE_ATTACH:       "ATTACH",
+	duckdb.STATEMENT_TYPE_DETACH:       "DETACH",
+	duckdb.STATEMENT_TYPE_MULTI:        "multi-statement",
+}
+
+// CheckReadOnlyQuery checks that query is a single read-only statement: a
+// SELECT, including WITH, DESCRIBE, SUMMARIZE and SHOW, or an EXPLAIN of
+// one. The query is parsed and bound on conn, but not executed.
+// Returns an error wrapping ErrStatementNotAllowed if the query is rejected,
+// or DuckDB's error if it does not prepare.
+func CheckReadOnlyQuery(ctx context.Context, conn *sql.DB, query string) error {
+	if m := explainPattern.FindStringSubmatch(query); m != nil {
+		// EXPLAIN ANALYZE runs the statement it explains, so that must be read-only too
+		if err := CheckReadOnlyQuery(ctx, conn, m[1]); err != nil {
+			return fmt.Errorf("EXPLAIN: %w", err)
+		}
+		return nil
+	}
+
+	sqlConn, err := conn.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer sqlConn.Close()
+
+	return sqlConn.Raw(func(driverConn any) error {
+		duckConn, ok := driverConn.(*duckdb.Conn)
+		if !ok {
+			return fmt.Errorf("not a DuckDB connection: %T", driverConn)
+		}
+		// Prepare, unlike PrepareContext, refuses multiple statements rather
+		// than executing all but the last
+		stmt, err := duckConn.Prepare(query)
+		if err != nil {
+			var duckErr *duckdb.Error
+			if errors.As(err, &duckErr) {
+				return err
+			}
+			return fmt.Errorf("%w: the query must be exactly one SQL statement", ErrStatementNotAllowed)
+		}
+		defer stmt.Close()
+		stmtType, err := stmt.(*duckdb.Stmt).StatementType()
+		if err != nil {
+			return fmt.Errorf("failed to get statement type: %w", err)
+		}
+		switch stmtType {
+		case duckdb.STATEMENT_TYPE_SELECT:
+			return nil
+		case duckdb.STATEMENT_TYPE_EXPLAIN:
+			// explainPattern did not match, so the explained statement is unknown
+			return fmt.Errorf("%w: EXPLAIN must be followed directly by the statement it explains", ErrStatementNotAllowed)
+		}
+		name, ok := statementTypeNames[stmtType]
+		if !ok {
+			name = fmt.Sprintf("type %d", stmtType)
+		}
+		return fmt.Errorf("%w: %s statements are not permitted; only a single read-only SELECT, WITH, DESCRIBE, SUMMARIZE or EXPLAIN", ErrStatementNotAllowed, name)
+	})
+}
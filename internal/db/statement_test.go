@@ -0,0 +1,84 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCheckReadOnlyQuery(t *testing.T) {
+	conn := openTestDB(t)
+	if _, err := conn.Exec("CREATE TABLE brands (brand_name VARCHAR, thc DOUBLE)"); err != nil {
+		t.Fatal(err)
+	}
+
+	allowed := []string{
+		"SELECT 1",
+		"SELECT * FROM brands;",
+		"  select brand_name from brands where thc > $1",
+		"WITH strong AS (SELECT * FROM brands WHERE thc > 80) SELECT count(*) FROM strong",
+		"FROM brands",
+		"DESCRIBE brands",
+		"SUMMARIZE brands",
+		"SHOW TABLES",
+		"EXPLAIN SELECT * FROM brands",
+		"EXPLAIN ANALYZE SELECT * FROM brands",
+	}
+	for _, query := range allowed {
+		if err := CheckReadOnlyQuery(context.Background(), conn, query); err != nil {
+			t.Errorf("%q: unexpected error: %v", query, err)
+		}
+	}
+
+	rejected := []string{
+		"SET enable_external_access = true",
+		"RESET enable_external_access",
+		"SET VARIABLE x = 1",
+		"PRAGMA enable_profiling",
+		"ATTACH ':memory:' AS other",
+		"DETACH other",
+		"USE other",
+		"INSTALL httpfs",
+		"LOAD httpfs",
+		"CALL pragma_version()",
+		"CHECKPOINT",
+		"EXPORT DATABASE '/tmp/dank-export'",
+		"COPY brands TO '/tmp/brands.csv'",
+		"BEGIN TRANSACTION",
+		"CREATE TABLE t (a INTEGER)",
+		"CREATE MACRO m(a) AS a",
+		"INSERT INTO brands VALUES ('x', 1)",
+		"UPDATE brands SET thc = 0",
+		"DELETE FROM brands",
+		"DROP TABLE brands",
+		"ALTER TABLE brands ADD COLUMN c INTEGER",
+		"VACUUM",
+		"SELECT 1; SET enable_external_access = true",
+		"SET enable_external_access = true; SELECT 1",
+		"SELECT 1; SELECT 2",
+		"EXPLAIN ANALYZE CREATE TABLE t AS SELECT 1",
+		"EXPLAIN ANALYZE SELECT 1; SET threads = 1",
+		"/* hidden */ EXPLAIN ANALYZE DELETE FROM brands",
+		"",
+		"-- only a comment",
+	}
+	for _, query := range rejected {
+		err := CheckReadOnlyQuery(context.Background(), conn, query)
+		if !errors.Is(err, ErrStatementNotAllowed) {
+			t.Errorf("%q: expected ErrStatementNotAllowed, got %v", query, err)
+		}
+	}
+
+	// Queries that fail to parse return DuckDB's error
+	if err := CheckReadOnlyQuery(context.Background(), conn, "SELEC 1"); err == nil || errors.Is(err, ErrStatementNotAllowed) {
+		t.Errorf("syntax error: unexpected error %v", err)
+	}
+
+	// Nothing was executed
+	var n int
+	if err := conn.QueryRow("SELECT count(*) FROM duckdb_tables() WHERE table_name = 't'").Scan(&n); err != nil || n != 0 {
+		t.Errorf("rejected statement was executed: n=%d, err=%v", n, err)
+	}
+}
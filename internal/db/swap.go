@@ -0,0 +1,90 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// Swappable holds the database connection currently being served, which
+// may be replaced by a newer one while requests are running on it. Each
+// connection is held with info of type T describing it, such as the
+// snapshots it serves, which is swapped along with it.
+type Swappable[T any] struct {
+	mu       sync.Mutex
+	current  *swappableConn[T]
+	onSwap   []func()
+	isClosed bool
+}
+
+// swappableConn is a connection and its in-flight users.
+type swappableConn[T any] struct {
+	conn  *sql.DB
+	info  T
+	users sync.WaitGroup
+}
+
+// NewSwappable returns a Swappable serving conn, described by info.
+func NewSwappable[T any](conn *sql.DB, info T) *Swappable[T] {
+	return &Swappable[T]{current: &swappableConn[T]{conn: conn, info: info}}
+}
+
+// Acquire returns the current connection and its info. The connection
+// will not be closed until release is called. Every Acquire must be paired
+// with a release.
+func (s *Swappable[T]) Acquire() (conn *sql.DB, info T, release func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.current
+	if s.isClosed {
+		// Close is already waiting on users; the closed connection fails queries
+		return current.conn, current.info, func() {}
+	}
+	current.users.Add(1)
+	return current.conn, current.info, current.users.Done
+}
+
+// OnSwap registers fn to be called after each Swap.
+func (s *Swappable[T]) OnSwap(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onSwap = append(s.onSwap, fn)
+}
+
+// Swap makes conn, described by info, the current connection for new
+// Acquires, then waits for the users of the previous connection to release
+// it, and closes it.
+// If s is closed, conn is closed instead.
+// Returns an error, if any, including from closing the previous connection.
+func (s *Swappable[T]) Swap(conn *sql.DB, info T) error {
+	s.mu.Lock()
+	if s.isClosed {
+		s.mu.Unlock()
+		conn.Close()
+		return errors.New("swappable database is closed")
+	}
+	old := s.current
+	s.current = &swappableConn[T]{conn: conn, info: info}
+	onSwap := append([]func(){}, s.onSwap...)
+	s.mu.Unlock()
+
+	for _, fn := range onSwap {
+		fn()
+	}
+	old.users.Wait()
+	return old.conn.Close()
+}
+
+// Close waits for the users of the current connection to release it, and
+// closes it. Later Swaps fail.
+func (s *Swappable[T]) Close() error {
+	s.mu.Lock()
+	s.isClosed = true
+	current := s.current
+	s.mu.Unlock()
+
+	current.users.Wait()
+	return current.conn.Close()
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSwappable(t *testing.T) {
+	first, second := openTestDB(t), openTestDB(t)
+	swappable := NewSwappable(first, "first")
+	swaps := 0
+	swappable.OnSwap(func() { swaps++ })
+
+	conn, info, release := swappable.Acquire()
+	if conn != first || info != "first" {
+		t.Fatal("expected first connection")
+	}
+
+	swapped := make(chan error)
+	go func() { swapped <- swappable.Swap(second, "second") }()
+
+	// New requests are served by the new connection while the old one drains
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, info, releaseNew := swappable.Acquire()
+		releaseNew()
+		if conn == second {
+			if info != "second" {
+				t.Fatalf("info %v swapped in with second connection", info)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("swap did not take effect")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-swapped:
+		t.Fatalf("swap finished before in-flight request released: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := first.Ping(); err != nil {
+		t.Fatalf("old connection closed while in use: %v", err)
+	}
+
+	release()
+	if err := <-swapped; err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Ping(); err == nil {
+		t.Error("expected old connection to be closed")
+	}
+	if swaps != 1 {
+		t.Errorf("OnSwap called %d times; want 1", swaps)
+	}
+
+	if err := swappable.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := swappable.Swap(openTestDB(t), "third"); err == nil {
+		t.Error("expected error swapping after Close")
+	}
+}
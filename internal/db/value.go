@@ -0,0 +1,407 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package db
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+const (
+	BlobHex    = "hex"    // BLOBs rendered as lowercase hexadecimal
+	BlobBase64 = "base64" // BLOBs rendered as standard base64
+)
+
+// ValueOptions configures how column values are rendered.
+type ValueOptions struct {
+	NullToken    string // Text for NULL in text formats (CSV, Markdown); JSON always uses null
+	BlobEncoding string // BlobHex (the default if empty) or BlobBase64
+}
+
+// Validate checks the options, returning nil on success.
+func (o ValueOptions) Validate() error {
+	switch o.BlobEncoding {
+	case "", BlobHex, BlobBase64:
+		return nil
+	default:
+		return fmt.Errorf("unsupported blob encoding %q, must be %q or %q", o.BlobEncoding, BlobHex, BlobBase64)
+	}
+}
+
+// Column is a result column, which renders its values according to its
+// DuckDB type. Temporal values are rendered as ISO-8601, DECIMALs exactly,
+// UUIDs in canonical form, BLOBs as hex or base64, and nested types
+// (LIST, ARRAY, STRUCT, MAP, UNION, JSON) as JSON.
+type Column struct {
+	Name         string // Column name
+	DatabaseType string // DuckDB type name, e.g. "DECIMAL(10,3)" or "INTEGER[]"
+
+	opts ValueOptions
+}
+
+// NewColumns returns a Column for each column of rows, rendering values
+// with opts.
+func NewColumns(rows *sql.Rows, opts ValueOptions) ([]Column, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("error getting column types: %w", err)
+	}
+	columns := make([]Column, len(types))
+	for i, ct := range types {
+		columns[i] = Column{Name: ct.Name(), DatabaseType: ct.DatabaseTypeName(), opts: opts}
+	}
+	return columns, nil
+}
+
+// ColumnNames returns the names of columns.
+func ColumnNames(columns []Column) []string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// baseType returns the DuckDB type without parameters, e.g. "DECIMAL" for
+// "DECIMAL(10,3)", and "LIST" for any list or array type.
+func (c Column) baseType() string {
+	return baseType(c.DatabaseType)
+}
+
+func baseType(databaseType string) string {
+	if strings.HasSuffix(databaseType, "]") {
+		return "LIST"
+	}
+	if i := strings.IndexByte(databaseType, '('); i >= 0 {
+		return databaseType[:i]
+	}
+	return databaseType
+}
+
+// Text renders value, a scanned value of the column, as text.
+func (c Column) Text(value any) string {
+	if value == nil {
+		return c.opts.NullToken
+	}
+	if t, ok := value.(time.Time); ok {
+		if s, ok := formatTemporal(c.baseType(), t); ok {
+			return s
+		}
+	}
+	if b, ok := value.([]byte); ok && c.baseType() == "UUID" && len(b) == 16 {
+		return formatUUID(b)
+	}
+	return c.opts.text(c.DatabaseType, value)
+}
+
+// JSONValue converts value, a scanned value of the column, to a value
+// that encodes as JSON. Numbers that a float64 cannot hold exactly, such
+// as DECIMALs and HUGEINTs, become json.Numbers.
+func (c Column) JSONValue(value any) any {
+	if value == nil {
+		return nil
+	}
+	return c.opts.jsonValue(c.DatabaseType, value)
+}
+
+// text renders a value of DuckDB type databaseType that needs no special
+// handling as text.
+func (o ValueOptions) text(databaseType string, value any) string {
+	switch v := value.(type) {
+	case nil:
+		return o.NullToken
+	case string:
+		return v
+	case []byte:
+		return o.formatBlob(v)
+	case float64:
+		return formatFloat(v, 64)
+	case float32:
+		return formatFloat(float64(v), 32)
+	case duckdb.Decimal:
+		return formatDecimal(v)
+	case *big.Int:
+		return v.String()
+	case duckdb.Interval:
+		return formatInterval(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []any, map[string]any, duckdb.OrderedMap, duckdb.Union:
+		if b, err := json.Marshal(o.jsonValue(databaseType, v)); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// jsonValue converts a value of DuckDB type databaseType, possibly nested,
+// to a value that encodes as JSON. Elements of nested values are converted
+// according to their own types, as parsed from databaseType.
+func (o ValueOptions) jsonValue(databaseType string, value any) any {
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return formatFloat(v, 64)
+		}
+		return v
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return formatFloat(float64(v), 32)
+		}
+		return v
+	case duckdb.Decimal:
+		return json.Number(formatDecimal(v))
+	case *big.Int:
+		return json.Number(v.String())
+	case duckdb.Interval:
+		return formatInterval(v)
+	case time.Time:
+		if s, ok := formatTemporal(baseType(databaseType), v); ok {
+			return s
+		}
+		// Of unknown type, so rendered as it was scanned, without a zone
+		return v.Format("2006-01-02T15:04:05.999999999")
+	case []byte:
+		if baseType(databaseType) == "UUID" && len(v) == 16 {
+			return formatUUID(v)
+		}
+		return o.formatBlob(v)
+	case []any:
+		elemType := elementType(databaseType)
+		list := make([]any, len(v))
+		for i, elem := range v {
+			list[i] = o.jsonValue(elemType, elem)
+		}
+		return list
+	case map[string]any:
+		fieldTypes := fieldTypes(databaseType)
+		obj := make(map[string]any, len(v))
+		for key, elem := range v {
+			obj[key] = o.jsonValue(fieldTypes[key], elem)
+		}
+		return obj
+	case duckdb.OrderedMap:
+		return o.mapJSONValue(databaseType, v)
+	case duckdb.Union:
+		return o.jsonValue(fieldTypes(databaseType)[v.Tag], v.Value)
+	default:
+		return v
+	}
+}
+
+// mapJSONValue converts a DuckDB MAP to a JSON object if its keys are all
+// strings, or otherwise to an array of {"key", "value"} objects.
+func (o ValueOptions) mapJSONValue(databaseType string, m duckdb.OrderedMap) any {
+	var keyType, valueType string
+	if params := typeParams(databaseType, "MAP"); len(params) == 2 {
+		keyType, valueType = params[0], params[1]
+	}
+	keys, values := m.Keys(), m.Values()
+	obj := make(map[string]any, len(keys))
+	for i, key := range keys {
+		s, ok := key.(string)
+		if !ok {
+			entries := make([]any, len(keys))
+			for j := range keys {
+				entries[j] = map[string]any{"key": o.jsonValue(keyType, keys[j]), "value": o.jsonValue(valueType, values[j])}
+			}
+			return entries
+		}
+		obj[s] = o.jsonValue(valueType, values[i])
+	}
+	return obj
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+// elementType returns the type of the elements of a LIST or ARRAY type,
+// e.g. "DATE" for "DATE[]" or "DATE[3]", or "" if databaseType is neither.
+func elementType(databaseType string) string {
+	if !strings.HasSuffix(databaseType, "]") {
+		return ""
+	}
+	if i := strings.LastIndexByte(databaseType, '['); i >= 0 {
+		return databaseType[:i]
+	}
+	return ""
+}
+
+// fieldTypes returns the types of the fields of a STRUCT or UNION type by
+// name, e.g. {"a": "DATE"} for `STRUCT("a" DATE)`, or nil if databaseType
+// is neither.
+func fieldTypes(databaseType string) map[string]string {
+	params := typeParams(databaseType, "STRUCT")
+	if params == nil {
+		params = typeParams(databaseType, "UNION")
+	}
+	types := make(map[string]string, len(params))
+	for _, param := range params {
+		var name, rest string
+		if strings.HasPrefix(param, `"`) {
+			// A quoted name, with its quotes doubled
+			end := 1
+			for end < len(param) && (param[end] != '"' || strings.HasPrefix(param[end:], `""`)) {
+				if param[end] == '"' {
+					end++
+				}
+				end++
+			}
+			if end >= len(param) {
+				continue
+			}
+			name, rest = strings.ReplaceAll(param[1:end], `""`, `"`), param[end+1:]
+		} else {
+			name, rest, _ = strings.Cut(param, " ")
+		}
+		types[name] = strings.TrimSpace(rest)
+	}
+	return types
+}
+
+// typeParams returns the comma-separated parameters of databaseType if it
+// is of type base, e.g. ["VARCHAR", "DATE"] for "MAP(VARCHAR, DATE)", or
+// nil if it is not.
+func typeParams(databaseType, base string) []string {
+	if !strings.HasPrefix(databaseType, base+"(") || !strings.HasSuffix(databaseType, ")") {
+		return nil
+	}
+	inner := databaseType[len(base)+1 : len(databaseType)-1]
+	var params []string
+	var depth, start int
+	var quoted bool
+	for i := 0; i < len(inner); i++ {
+		switch c := inner[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			params = append(params, strings.TrimSpace(inner[start:i]))
+			start = i + 1
+		}
+	}
+	return append(params, strings.TrimSpace(inner[start:]))
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+// formatTemporal renders t as ISO-8601 according to its DuckDB base type.
+// Returns false if baseType is not a temporal type.
+func formatTemporal(baseType string, t time.Time) (string, bool) {
+	switch baseType {
+	case "DATE":
+		return t.Format("2006-01-02"), true
+	case "TIME":
+		return t.Format("15:04:05.999999"), true
+	case "TIMETZ":
+		return t.Format("15:04:05.999999Z07:00"), true
+	case "TIMESTAMP", "TIMESTAMP_S", "TIMESTAMP_MS", "TIMESTAMP_NS":
+		return t.Format("2006-01-02T15:04:05.999999999"), true
+	case "TIMESTAMPTZ", "TIMESTAMP WITH TIME ZONE":
+		return t.UTC().Format(time.RFC3339Nano), true
+	default:
+		return "", false
+	}
+}
+
+// formatUUID renders a 16-byte UUID in its canonical 8-4-4-4-12 form.
+func formatUUID(b []byte) string {
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// formatBlob renders b with the configured blob encoding.
+func (o ValueOptions) formatBlob(b []byte) string {
+	if o.BlobEncoding == BlobBase64 {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return hex.EncodeToString(b)
+}
+
+// formatDecimal renders d exactly, keeping all of its scale's digits.
+func formatDecimal(d duckdb.Decimal) string {
+	if d.Value == nil {
+		return "0"
+	}
+	digits := new(big.Int).Abs(d.Value).String()
+	sign := ""
+	if d.Value.Sign() < 0 {
+		sign = "-"
+	}
+	scale := int(d.Scale)
+	if scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+// formatFloat renders f like encoding/json does, without exponents for
+// everyday magnitudes, and with DuckDB's names for non-finite values.
+func formatFloat(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		return strconv.FormatFloat(f, 'g', -1, bits)
+	}
+	return strconv.FormatFloat(f, 'f', -1, bits)
+}
+
+// formatInterval renders i as an ISO-8601 duration, e.g. P1Y2M3DT4H5M6.789S.
+func formatInterval(i duckdb.Interval) string {
+	var sb strings.Builder
+	sb.WriteByte('P')
+	if years := i.Months / 12; years != 0 {
+		fmt.Fprintf(&sb, "%dY", years)
+	}
+	if months := i.Months % 12; months != 0 {
+		fmt.Fprintf(&sb, "%dM", months)
+	}
+	if i.Days != 0 {
+		fmt.Fprintf(&sb, "%dD", i.Days)
+	}
+	if i.Micros != 0 {
+		sb.WriteByte('T')
+		micros := i.Micros
+		if hours := micros / int64(time.Hour/time.Microsecond); hours != 0 {
+			fmt.Fprintf(&sb, "%dH", hours)
+		}
+		if minutes := micros % int64(time.Hour/time.Microsecond) / int64(time.Minute/time.Microsecond); minutes != 0 {
+			fmt.Fprintf(&sb, "%dM", minutes)
+		}
+		if seconds := micros % int64(time.Minute/time.Microsecond); seconds != 0 {
+			sign := ""
+			if seconds < 0 {
+				sign, seconds = "-", -seconds
+			}
+			s := fmt.Sprintf("%d.%06d", seconds/1e6, seconds%1e6)
+			s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+			fmt.Fprintf(&sb, "%s%sS", sign, s)
+		}
+	}
+	if sb.Len() == 1 {
+		return "PT0S"
+	}
+	return sb.String()
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package db
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// renderValue runs "SELECT expr" and returns the value's text and JSON renderings.
+func renderValue(t *testing.T, expr string, opts ValueOptions) (string, string) {
+	t.Helper()
+	rows, err := openTestDB(t).Query("SELECT " + expr + " AS v")
+	if err != nil {
+		t.Fatalf("%s: %v", expr, err)
+	}
+	defer rows.Close()
+	columns, err := NewColumns(rows, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rows.Next() {
+		t.Fatalf("%s: no rows", expr)
+	}
+	var value any
+	if err := rows.Scan(&value); err != nil {
+		t.Fatalf("%s: %v", expr, err)
+	}
+	jsonValue, err := json.Marshal(columns[0].JSONValue(value))
+	if err != nil {
+		t.Fatalf("%s: %v", expr, err)
+	}
+	return columns[0].Text(value), string(jsonValue)
+}
+
+func TestColumn_Render(t *testing.T) {
+	cases := []struct {
+		expr string
+		text string
+		json string
+	}{
+		// Scalars
+		{"NULL", "", `null`},
+		{"true", "true", `true`},
+		{"-8::TINYINT", "-8", `-8`},
+		{"16::SMALLINT", "16", `16`},
+		{"32::INTEGER", "32", `32`},
+		{"64::BIGINT", "64", `64`},
+		{"8::UTINYINT", "8", `8`},
+		{"16::USMALLINT", "16", `16`},
+		{"32::UINTEGER", "32", `32`},
+		{"64::UBIGINT", "64", `64`},
+		{"170141183460469231731687303715884105727::HUGEINT", "170141183460469231731687303715884105727", `170141183460469231731687303715884105727`},
+		{"340282366920938463463374607431768211455::UHUGEINT", "340282366920938463463374607431768211455", `340282366920938463463374607431768211455`},
+		{"123456789012345678901234567890::VARINT", "123456789012345678901234567890", `123456789012345678901234567890`},
+		{"1.5::FLOAT", "1.5", `1.5`},
+		{"1500000::DOUBLE", "1500000", `1500000`},
+		{"1e-9::DOUBLE", "1e-09", `1e-9`},
+		{"'nan'::DOUBLE", "nan", `"nan"`},
+		{"'-inf'::DOUBLE", "-inf", `"-inf"`},
+		{"'hi'::VARCHAR", "hi", `"hi"`},
+		{"'a'::ENUM('a', 'b')", "a", `"a"`},
+
+		// Exact decimals keep their scale
+		{"12.300::DECIMAL(10,3)", "12.300", `12.300`},
+		{"-0.05::DECIMAL(4,2)", "-0.05", `-0.05`},
+		{"12345678901234567890123456789012345.678::DECIMAL(38,3)", "12345678901234567890123456789012345.678", `12345678901234567890123456789012345.678`},
+		{"7::DECIMAL(4,0)", "7", `7`},
+
+		// Temporal types are ISO-8601
+		{"DATE '2024-01-02'", "2024-01-02", `"2024-01-02"`},
+		{"TIME '12:34:56.789'", "12:34:56.789", `"12:34:56.789"`},
+		{"TIMETZ '12:34:56+02'", "12:34:56+02:00", `"12:34:56+02:00"`},
+		{"TIMESTAMP '2024-01-02 03:04:05.123456'", "2024-01-02T03:04:05.123456", `"2024-01-02T03:04:05.123456"`},
+		{"TIMESTAMP_S '2024-01-02 03:04:05'", "2024-01-02T03:04:05", `"2024-01-02T03:04:05"`},
+		{"TIMESTAMP_MS '2024-01-02 03:04:05.123'", "2024-01-02T03:04:05.123", `"2024-01-02T03:04:05.123"`},
+		{"TIMESTAMP_NS '2024-01-02 03:04:05.123456789'", "2024-01-02T03:04:05.123456789", `"2024-01-02T03:04:05.123456789"`},
+		{"TIMESTAMPTZ '2024-01-02 03:04:05+02'", "2024-01-02T01:04:05Z", `"2024-01-02T01:04:05Z"`},
+		{"INTERVAL '1 year 2 months 3 days 04:05:06.789'", "P1Y2M3DT4H5M6.789S", `"P1Y2M3DT4H5M6.789S"`},
+		{"INTERVAL '90 minutes'", "PT1H30M", `"PT1H30M"`},
+		{"INTERVAL '0 seconds'", "PT0S", `"PT0S"`},
+
+		// UUIDs and BLOBs
+		{"'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11'::UUID", "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", `"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"`},
+		{`'\xAA\x00Z'::BLOB`, "aa005a", `"aa005a"`},
+
+		// Nested types are JSON
+		{"[1, 2, NULL]", "[1,2,null]", `[1,2,null]`},
+		{"[1, 2]::INTEGER[2]", "[1,2]", `[1,2]`},
+		{"[1.50::DECIMAL(4,2)]", "[1.50]", `[1.50]`},
+		{"{'a': 1, 'b': 'x'}", `{"a":1,"b":"x"}`, `{"a":1,"b":"x"}`},
+		{"{'t': TIMESTAMP '2024-01-02 03:04:05'}", `{"t":"2024-01-02T03:04:05"}`, `{"t":"2024-01-02T03:04:05"}`},
+		{"{'d': [DATE '2024-01-02'], 'my \"t\"': TIME '01:02:03'}", `{"d":["2024-01-02"],"my \"t\"":"01:02:03"}`, `{"d":["2024-01-02"],"my \"t\"":"01:02:03"}`},
+		{"[TIMESTAMPTZ '2024-01-02 03:04:05+02']", `["2024-01-02T01:04:05Z"]`, `["2024-01-02T01:04:05Z"]`},
+		{"[['a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11'::UUID]]", `[["a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"]]`, `[["a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"]]`},
+		{"MAP {DATE '2024-01-02': TIME '01:02:03'}", `[{"key":"2024-01-02","value":"01:02:03"}]`, `[{"key":"2024-01-02","value":"01:02:03"}]`},
+		{"union_value(d := DATE '2024-01-02')", `"2024-01-02"`, `"2024-01-02"`},
+		{"MAP {'k': 1}", `{"k":1}`, `{"k":1}`},
+		{"MAP {1: 'one'}", `[{"key":1,"value":"one"}]`, `[{"key":1,"value":"one"}]`},
+		{"union_value(num := 2)", "2", `2`},
+		{`'{"a": [1, true]}'::JSON`, `{"a":[1,true]}`, `{"a":[1,true]}`},
+	}
+	for _, c := range cases {
+		text, jsonValue := renderValue(t, c.expr, ValueOptions{})
+		if text != c.text {
+			t.Errorf("%s: text = %q; want %q", c.expr, text, c.text)
+		}
+		if jsonValue != c.json {
+			t.Errorf("%s: json = %s; want %s", c.expr, jsonValue, c.json)
+		}
+	}
+}
+
+func TestColumn_RenderOptions(t *testing.T) {
+	opts := ValueOptions{NullToken: "NULL", BlobEncoding: BlobBase64}
+	if text, jsonValue := renderValue(t, "NULL::INTEGER", opts); text != "NULL" || jsonValue != `null` {
+		t.Errorf("null: text %q, json %s", text, jsonValue)
+	}
+	if text, jsonValue := renderValue(t, `'\xAA\x00Z'::BLOB`, opts); text != "qgBa" || jsonValue != `"qgBa"` {
+		t.Errorf("blob: text %q, json %s", text, jsonValue)
+	}
+	if err := (ValueOptions{BlobEncoding: "base32"}).Validate(); err == nil {
+		t.Error("expected error for unsupported blob encoding")
+	}
+}
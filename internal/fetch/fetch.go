@@ -6,44 +6,80 @@ package fetch
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/AgentDank/dank-mcp/data"
 	"github.com/AgentDank/dank-mcp/internal/catalog"
+	"github.com/AgentDank/dank-mcp/internal/retry"
+	"github.com/AgentDank/dank-mcp/internal/version"
 )
 
 const maxDownloadSize = 2 << 30 // 2 GiB
 
+// defaultClient downloads snapshots. It has no overall Timeout, which
+// would cut off reading a large snapshot however steadily it arrives;
+// connecting and waiting for a response are limited by its transport.
 var defaultClient = &http.Client{
-	Timeout: 30 * time.Second,
+	Transport: newSnapshotTransport(),
 }
 
-const cacheTTL = 7 * 24 * time.Hour
+func newSnapshotTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = 30 * time.Second
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	return transport
+}
 
 // Options configures a Download call.
 type Options struct {
-	// CatalogURL overrides the default catalog location. If empty, the
-	// default (catalog.DefaultURL) is used.
-	CatalogURL string
+	// Catalogs are the locations of the catalogs to find datasets in, as
+	// returned by catalog.Location, in order of precedence: a dataset is
+	// taken from the first catalog listing it. If empty, the default
+	// (catalog.DefaultURL) is used.
+	Catalogs []string
+
+	// CatalogKeys are the keys trusted to sign catalogs; a catalog that is
+	// not signed by one of them is refused. If nil, catalogs' signatures
+	// are not checked.
+	CatalogKeys catalog.Keyring
 
 	// CachePath is the final on-disk location for the installed DuckDB.
 	// Must be an absolute or otherwise already-resolved path.
 	CachePath string
 
-	// Client is the HTTP client used for all requests. If nil, http.DefaultClient.
+	// Client is the HTTP client used for all requests. If nil, catalogs are
+	// fetched with catalog's default client, and snapshots with a client
+	// that limits connecting and waiting for a response, but not reading it.
 	Client *http.Client
 
+	// Retry is the policy for retrying failed catalog and snapshot
+	// requests. If nil, retry.DefaultPolicy.
+	Retry *retry.Policy
+
 	// Logger receives progress and warning messages. Must not be nil.
 	Logger *slog.Logger
 
-	// Force bypasses the TTL check and always re-downloads. The TTL itself
-	// is added in a later task; default false.
+	// Refresh decides when to check for a newer snapshot than the one
+	// installed. If empty, RefreshTTL.
+	Refresh RefreshPolicy
+
+	// MaxAge is how old the installed snapshot may be before RefreshTTL
+	// checks for a newer one. If zero, DefaultMaxAge.
+	MaxAge time.Duration
+
+	// Force always re-downloads, as RefreshAlways; default false.
 	Force bool
 }
 
@@ -60,36 +96,86 @@ func Download(ctx context.Context, id string, opts Options) (string, error) {
 	if err := data.ValidateDatasetID(id); err != nil {
 		return "", err
 	}
-	catURL := opts.CatalogURL
-	if catURL == "" {
-		catURL = catalog.DefaultURL
+	catURLs := opts.Catalogs
+	if len(catURLs) == 0 {
+		catURLs = []string{catalog.DefaultURL}
+	}
+	policy := retry.DefaultPolicy
+	if opts.Retry != nil {
+		policy = *opts.Retry
+	}
+	refresh := opts.Refresh
+	switch {
+	case opts.Force:
+		refresh = RefreshAlways
+	case refresh == "":
+		refresh = RefreshTTL
+	}
+	if _, err := ParseRefreshPolicy(string(refresh)); err != nil {
+		return "", fmt.Errorf("fetch.Download: %w", err)
+	}
+	maxAge := opts.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
 	}
 
-	// TTL check before any network I/O
-	if !opts.Force {
-		if info, err := os.Stat(opts.CachePath); err == nil {
-			if time.Since(info.ModTime()) < cacheTTL {
-				opts.Logger.Info("cache fresh; skipping download", "id", id, "path", opts.CachePath)
-				return opts.CachePath, nil
-			}
+	// Freshness check before any network I/O
+	var manifest Manifest
+	var haveManifest bool
+	if info, err := os.Stat(opts.CachePath); err == nil && refresh != RefreshAlways {
+		switch {
+		case refresh == RefreshNever:
+			opts.Logger.Info("cache installed; not refreshing", "id", id, "path", opts.CachePath)
+			return opts.CachePath, nil
+		case refresh == RefreshTTL && time.Since(info.ModTime()) < maxAge:
+			opts.Logger.Info("cache fresh; skipping download", "id", id, "path", opts.CachePath)
+			return opts.CachePath, nil
 		}
+		// The cache is re-used if it is still current
+		manifest, err = ReadManifest(opts.CachePath)
+		haveManifest = err == nil
 	}
 
-	opts.Logger.Info("fetching catalog", "url", catURL)
-	cat, err := catalog.Fetch(ctx, catURL, opts.Client)
-	if err != nil {
+	var installed *Manifest
+	if haveManifest {
+		installed = &manifest
+	}
+	entry, catValidators, err := lookupEntry(ctx, id, catURLs, opts, policy, installed)
+	if errors.Is(err, catalog.ErrNotModified) {
+		return keepCache(opts, id, "catalog not modified; cache is current"), nil
+	}
+	var fetchErr *catalogFetchError
+	if errors.As(err, &fetchErr) {
 		// If there's a usable cache, degrade gracefully.
 		if info, statErr := os.Stat(opts.CachePath); statErr == nil {
 			opts.Logger.Warn("catalog fetch failed; using stale cache",
 				"err", err, "path", opts.CachePath, "age", time.Since(info.ModTime()).String())
 			return opts.CachePath, nil
 		}
-		return "", fmt.Errorf("catalog: %w", err)
 	}
-	entry, err := cat.Lookup(id)
 	if err != nil {
 		return "", err
 	}
+	catURL := entry.Source
+	if haveManifest && manifest.SHA256 == entry.SHA256 {
+		manifest.UpdatedAt, manifest.CatalogURL, manifest.Catalog = entry.UpdatedAt, catURL, catValidators
+		manifest.CatalogKeyID = entry.KeyID
+		manifest.Title, manifest.Description, manifest.Metadata = entry.Title, entry.Description, entry.Metadata
+		if err := writeManifest(opts.CachePath, manifest); err != nil {
+			opts.Logger.Warn("failed to update manifest", "err", err)
+		}
+		return keepCache(opts, id, "snapshot unchanged in catalog; cache is current"), nil
+	}
+
+	if err := entry.CheckVersion(version.Get()); err != nil {
+		err = fmt.Errorf("dataset %s %w", id, err)
+		if info, statErr := os.Stat(opts.CachePath); statErr == nil {
+			opts.Logger.Warn("new snapshot is not supported; using stale cache",
+				"err", err, "path", opts.CachePath, "age", time.Since(info.ModTime()).String())
+			return opts.CachePath, nil
+		}
+		return "", err
+	}
 
 	if err := os.MkdirAll(filepath.Dir(opts.CachePath), 0o755); err != nil {
 		return "", fmt.Errorf("mkdir cache dir: %w", err)
@@ -97,17 +183,35 @@ func Download(ctx context.Context, id string, opts Options) (string, error) {
 	partialPath := opts.CachePath + ".zst.partial"
 	newPath := opts.CachePath + ".new"
 
-	// Always clean up partial / new on exit (unless rename succeeded).
+	// Always clean up new on exit, and partial once installed. Otherwise the
+	// partial download is kept so a later attempt can resume it.
 	var renamed bool
 	defer func() {
-		os.Remove(partialPath)
-		if !renamed {
+		if renamed {
+			removePartial(partialPath)
+		} else {
 			os.Remove(newPath)
 		}
 	}()
 
+	var prevSnapshot catalog.Validators
+	if haveManifest && manifest.URL == entry.DuckDBURL {
+		prevSnapshot = manifest.Snapshot
+	}
 	opts.Logger.Info("downloading", "id", id, "url", entry.DuckDBURL)
-	if err := downloadVerified(ctx, opts.Client, entry.DuckDBURL, partialPath, entry.SHA256); err != nil {
+	var snapValidators catalog.Validators
+	if strings.HasPrefix(entry.DuckDBURL, "file://") {
+		snapValidators, err = copyVerified(entry.DuckDBURL, partialPath, entry.SHA256, prevSnapshot)
+	} else {
+		snapValidators, err = downloadVerified(ctx, opts.Client, policy, opts.Logger, entry.DuckDBURL, partialPath, entry.SHA256, prevSnapshot)
+	}
+	if errors.Is(err, catalog.ErrNotModified) {
+		// Not touched, so the next check tries again once the snapshot is updated
+		opts.Logger.Warn("catalog lists a new sha256 but the snapshot is not modified; keeping cache",
+			"id", id, "url", entry.DuckDBURL)
+		return opts.CachePath, nil
+	}
+	if err != nil {
 		if info, statErr := os.Stat(opts.CachePath); statErr == nil {
 			opts.Logger.Warn("download failed; using stale cache",
 				"err", err, "path", opts.CachePath, "age", time.Since(info.ModTime()).String())
@@ -116,7 +220,8 @@ func Download(ctx context.Context, id string, opts Options) (string, error) {
 		return "", err
 	}
 
-	if err := decompressFile(partialPath, newPath); err != nil {
+	installedSHA256, err := decompressFile(partialPath, newPath)
+	if err != nil {
 		return "", err
 	}
 
@@ -125,6 +230,25 @@ func Download(ctx context.Context, id string, opts Options) (string, error) {
 	}
 	renamed = true
 
+	newManifest := Manifest{
+		ID:           id,
+		Title:        entry.Title,
+		Description:  entry.Description,
+		Metadata:     entry.Metadata,
+		SHA256:       entry.SHA256,
+		UpdatedAt:    entry.UpdatedAt,
+		DuckDBSHA256: installedSHA256,
+		URL:          entry.DuckDBURL,
+		Snapshot:     snapValidators,
+		CatalogURL:   catURL,
+		Catalog:      catValidators,
+		CatalogKeyID: entry.KeyID,
+		DownloadedAt: time.Now().UTC(),
+	}
+	if err := writeManifest(opts.CachePath, newManifest); err != nil {
+		opts.Logger.Warn("failed to write manifest", "err", err)
+	}
+
 	info, _ := os.Stat(opts.CachePath)
 	if info != nil {
 		opts.Logger.Info("downloaded", "id", id, "bytes", info.Size())
@@ -132,56 +256,206 @@ func Download(ctx context.Context, id string, opts Options) (string, error) {
 	return opts.CachePath, nil
 }
 
-func downloadVerified(ctx context.Context, client *http.Client, url, partialPath, sha256Hex string) error {
+// catalogFetchError is a failure to fetch a catalog.
+type catalogFetchError struct {
+	err error
+}
+
+func (e *catalogFetchError) Error() string { return "catalog: " + e.err.Error() }
+func (e *catalogFetchError) Unwrap() error { return e.err }
+
+// lookupEntry fetches the catalogs at catURLs, in order of precedence,
+// until one lists id, returning its entry and that catalog's validators.
+// The catalog that installed was found in, if any, is requested
+// conditionally, unless it was not verified with a key now trusted; if it
+// is reached and has not changed, returns catalog.ErrNotModified.
+// Failures to fetch or verify a catalog are *catalogFetchError.
+func lookupEntry(ctx context.Context, id string, catURLs []string, opts Options, policy retry.Policy, installed *Manifest) (catalog.DatasetEntry, catalog.Validators, error) {
+	var fetched []catalog.Catalog
+	for _, catURL := range catURLs {
+		// The catalog request is conditional on it having changed since last checked
+		var prev catalog.Validators
+		if installed != nil && installed.CatalogURL == catURL &&
+			(opts.CatalogKeys == nil || opts.CatalogKeys.Trusts(installed.CatalogKeyID)) {
+			prev = installed.Catalog
+		}
+		opts.Logger.Info("fetching catalog", "url", catURL)
+		cat, validators, err := catalog.FetchIfModified(ctx, catURL, opts.Client, policy, opts.CatalogKeys, prev)
+		if errors.Is(err, catalog.ErrNotModified) {
+			return catalog.DatasetEntry{}, validators, err
+		}
+		if err != nil {
+			return catalog.DatasetEntry{}, catalog.Validators{}, &catalogFetchError{err}
+		}
+		if entry, ok := cat.Datasets[id]; ok {
+			return entry, validators, nil
+		}
+		fetched = append(fetched, cat)
+	}
+	merged, _ := catalog.Merge(fetched...)
+	_, err := merged.Lookup(id)
+	return catalog.DatasetEntry{}, catalog.Validators{}, err
+}
+
+// keepCache logs msg and touches the current cache, restarting its TTL.
+// Returns CachePath.
+func keepCache(opts Options, id, msg string) string {
+	opts.Logger.Info(msg, "id", id, "path", opts.CachePath)
+	if err := touchCache(opts.CachePath); err != nil {
+		opts.Logger.Warn("failed to touch cache", "err", err, "path", opts.CachePath)
+	}
+	return opts.CachePath
+}
+
+// errInterrupted is wrapped by downloadAttempt when the snapshot's body
+// fails transiently, leaving a partial download to resume.
+var errInterrupted = errors.New("download interrupted")
+
+// downloadVerified downloads url to partialPath, verifying its sha256.
+// A partial download of the same snapshot left by an earlier attempt is
+// resumed with a Range request, after re-hashing the bytes already there;
+// if the server ignores the range or the snapshot's ETag has changed, the
+// download starts over. A download with the wrong digest is removed.
+// A fresh download is conditional on the snapshot having changed since
+// prev was recorded, returning catalog.ErrNotModified if it has not.
+// Requests are retried with policy, as are downloads interrupted while
+// reading the body, which resume where they stopped; an interruption
+// after the download made progress starts the count of attempts over.
+// Returns the validators of the downloaded snapshot.
+func downloadVerified(ctx context.Context, client *http.Client, policy retry.Policy, logger *slog.Logger, url, partialPath, sha256Hex string, prev catalog.Validators) (catalog.Validators, error) {
 	if client == nil {
 		client = defaultClient
 	}
+	attempt := 0
+	for {
+		before := fileSize(partialPath)
+		validators, err := downloadAttempt(ctx, client, policy, logger, url, partialPath, sha256Hex, prev)
+		if !errors.Is(err, errInterrupted) {
+			return validators, err
+		}
+		// Only interruptions that made no progress use up attempts
+		if fileSize(partialPath) > before {
+			attempt = 0
+		}
+		if attempt++; attempt >= policy.Attempts {
+			return validators, err
+		}
+		logger.Warn("download interrupted; resuming", "url", url, "attempt", attempt, "error", err.Error())
+		if err := policy.Wait(ctx, attempt); err != nil {
+			return catalog.Validators{}, err
+		}
+	}
+}
+
+// downloadAttempt is one attempt of downloadVerified, which resumes any
+// partial download and, if it must, restarts it.
+func downloadAttempt(ctx context.Context, client *http.Client, policy retry.Policy, logger *slog.Logger, url, partialPath, sha256Hex string, prev catalog.Validators) (catalog.Validators, error) {
+
+	h := sha256.New()
+	var offset int64
+	meta, err := readPartialMeta(partialPath)
+	if err == nil && meta.URL == url && meta.SHA256 == sha256Hex {
+		if offset, err = hashFile(partialPath, h); err != nil {
+			offset = 0
+			h.Reset()
+		}
+	}
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+		return catalog.Validators{}, fmt.Errorf("build request: %w", err)
+	}
+	if offset == 0 {
+		prev.SetConditional(req)
+	} else {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if isStrongETag(meta.ETag) {
+			// Servers send the whole snapshot instead if it has changed
+			req.Header.Set("If-Range", meta.ETag)
+		}
 	}
-	resp, err := client.Do(req)
+	resp, err := policy.Do(client, req)
 	if err != nil {
-		return fmt.Errorf("GET %s: %w", url, err)
+		return catalog.Validators{}, fmt.Errorf("GET %s: %w", url, err)
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		if contentRangeStart(resp) != offset || (meta.ETag != "" && resp.Header.Get("ETag") != meta.ETag) {
+			logger.Warn("partial download does not match the server's; restarting", "url", url)
+			resp.Body.Close()
+			removePartial(partialPath)
+			return downloadAttempt(ctx, client, policy, logger, url, partialPath, sha256Hex, prev)
+		}
+		logger.Info("resuming download", "url", url, "offset", offset)
+		flags |= os.O_APPEND
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The partial download may have completed before it was installed
+		if hex.EncodeToString(h.Sum(nil)) == sha256Hex {
+			return catalog.Validators{ETag: meta.ETag}, nil
+		}
+		removePartial(partialPath)
+		return downloadAttempt(ctx, client, policy, logger, url, partialPath, sha256Hex, prev)
+	case offset == 0 && resp.StatusCode == http.StatusNotModified && !prev.IsZero():
+		return prev, catalog.ErrNotModified
+	case resp.StatusCode == http.StatusOK:
+		if offset > 0 {
+			logger.Info("server sent the whole snapshot; restarting download", "url", url)
+		}
+		offset = 0
+		h.Reset()
+		flags |= os.O_TRUNC
+	default:
+		return catalog.Validators{}, fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
 	}
-	if resp.ContentLength > maxDownloadSize {
-		return fmt.Errorf("GET %s: response too large (%d bytes)", url, resp.ContentLength)
+	if resp.ContentLength > maxDownloadSize-offset {
+		return catalog.Validators{}, fmt.Errorf("GET %s: response too large (%d bytes)", url, offset+resp.ContentLength)
 	}
 
-	f, err := os.Create(partialPath)
+	// Record what the partial file is a prefix of, so it can be resumed
+	if err := writePartialMeta(partialPath, partialMeta{URL: url, SHA256: sha256Hex, ETag: resp.Header.Get("ETag")}); err != nil {
+		return catalog.Validators{}, err
+	}
+	f, err := os.OpenFile(partialPath, flags, 0o644)
 	if err != nil {
-		return fmt.Errorf("create partial: %w", err)
+		return catalog.Validators{}, fmt.Errorf("create partial: %w", err)
 	}
 	defer f.Close()
 
 	reporter := newProgressReporter(resp.ContentLength)
 	defer reporter.finish()
 
-	if _, err := copyAndVerify(f, reporter.wrap(io.LimitReader(resp.Body, maxDownloadSize)), sha256Hex); err != nil {
-		return err
+	if _, err := copyAndVerifyHash(f, reporter.wrap(io.LimitReader(resp.Body, maxDownloadSize-offset)), h, sha256Hex); err != nil {
+		if errors.Is(err, errSHA256Mismatch) {
+			removePartial(partialPath)
+		} else if retry.IsTransient(err) && ctx.Err() == nil {
+			return catalog.Validators{}, fmt.Errorf("GET %s: %w: %w", url, errInterrupted, err)
+		}
+		return catalog.Validators{}, err
 	}
-	return nil
+	return catalog.ValidatorsOf(resp), nil
 }
 
-func decompressFile(srcPath, dstPath string) error {
+// decompressFile decompresses srcPath to dstPath, returning the sha256
+// of the decompressed file.
+func decompressFile(srcPath, dstPath string) (string, error) {
 	in, err := os.Open(srcPath)
 	if err != nil {
-		return fmt.Errorf("open compressed: %w", err)
+		return "", fmt.Errorf("open compressed: %w", err)
 	}
 	defer in.Close()
 
 	out, err := os.Create(dstPath)
 	if err != nil {
-		return fmt.Errorf("create decompressed: %w", err)
+		return "", fmt.Errorf("create decompressed: %w", err)
 	}
 	defer out.Close()
 
-	if err := decompressZstd(in, out); err != nil {
-		return err
+	h := sha256.New()
+	if err := decompressZstd(in, io.MultiWriter(out, h)); err != nil {
+		return "", err
 	}
-	return nil
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
@@ -14,16 +14,25 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/AgentDank/dank-mcp/internal/catalog"
+	"github.com/AgentDank/dank-mcp/internal/retry"
 	"github.com/klauspost/compress/zstd"
 )
 
 // buildSnapshot returns (compressedBytes, sha256Hex, originalPayload).
 func buildSnapshot(t *testing.T) ([]byte, string, []byte) {
 	t.Helper()
-	payload := []byte("fake duckdb bytes for test")
+	return buildSnapshotOf(t, []byte("fake duckdb bytes for test"))
+}
+
+// buildSnapshotOf is buildSnapshot for payload.
+func buildSnapshotOf(t *testing.T, payload []byte) ([]byte, string, []byte) {
+	t.Helper()
 	var buf bytes.Buffer
 	w, err := zstd.NewWriter(&buf)
 	if err != nil {
@@ -37,7 +46,20 @@ func buildSnapshot(t *testing.T) ([]byte, string, []byte) {
 	return buf.Bytes(), hex.EncodeToString(sum[:]), payload
 }
 
+// fastRetry retries without waiting long, for tests of failing servers.
+var fastRetry = retry.Policy{Attempts: 4, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
 func startServer(t *testing.T, compressed []byte, sha256Hex string) *httptest.Server {
+	t.Helper()
+	return startCatalogServer(t, sha256Hex, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(compressed)))
+		w.Write(compressed)
+	})
+}
+
+// startCatalogServer serves a catalog listing us/ct with sha256Hex, and
+// serves its snapshot with snapshot.
+func startCatalogServer(t *testing.T, sha256Hex string, snapshot http.HandlerFunc) *httptest.Server {
 	t.Helper()
 	catalogTpl := fmt.Sprintf(`{
   "version": 1,
@@ -58,8 +80,7 @@ func startServer(t *testing.T, compressed []byte, sha256Hex string) *httptest.Se
 			w.Header().Set("Content-Type", "application/json")
 			fmt.Fprintf(w, catalogTpl, "http://"+r.Host)
 		case "/snapshot.zst":
-			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(compressed)))
-			w.Write(compressed)
+			snapshot(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -76,10 +97,10 @@ func TestDownload_HappyPath(t *testing.T) {
 	cachePath := filepath.Join(tmp, "dank-data.duckdb")
 
 	opts := Options{
-		CatalogURL: srv.URL + "/catalog.json",
-		CachePath:  cachePath,
-		Client:     srv.Client(),
-		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Catalogs:  []string{srv.URL + "/catalog.json"},
+		CachePath: cachePath,
+		Client:    srv.Client(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
 	}
 	gotPath, err := Download(context.Background(), "us/ct", opts)
 	if err != nil {
@@ -107,10 +128,10 @@ func TestDownload_SHA256Mismatch(t *testing.T) {
 	cachePath := filepath.Join(tmp, "dank-data.duckdb")
 
 	_, err := Download(context.Background(), "us/ct", Options{
-		CatalogURL: srv.URL + "/catalog.json",
-		CachePath:  cachePath,
-		Client:     srv.Client(),
-		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Catalogs:  []string{srv.URL + "/catalog.json"},
+		CachePath: cachePath,
+		Client:    srv.Client(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
 	})
 	if err == nil {
 		t.Fatal("expected sha256 mismatch error")
@@ -118,6 +139,9 @@ func TestDownload_SHA256Mismatch(t *testing.T) {
 	if _, statErr := os.Stat(cachePath); statErr == nil {
 		t.Error("cached file should not exist after mismatch")
 	}
+	if _, statErr := os.Stat(cachePath + ".zst.partial"); statErr == nil {
+		t.Error("mismatched partial download should not be kept")
+	}
 }
 
 func TestDownload_Unknown(t *testing.T) {
@@ -129,10 +153,10 @@ func TestDownload_Unknown(t *testing.T) {
 	cachePath := filepath.Join(tmp, "dank-data.duckdb")
 
 	_, err := Download(context.Background(), "us/zz", Options{
-		CatalogURL: srv.URL + "/catalog.json",
-		CachePath:  cachePath,
-		Client:     srv.Client(),
-		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Catalogs:  []string{srv.URL + "/catalog.json"},
+		CachePath: cachePath,
+		Client:    srv.Client(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
 	})
 	if err == nil {
 		t.Fatal("expected unknown-id error")
@@ -147,10 +171,10 @@ func TestDownload_Catalog404(t *testing.T) {
 
 	tmp := t.TempDir()
 	_, err := Download(context.Background(), "us/ct", Options{
-		CatalogURL: srv.URL + "/catalog.json",
-		CachePath:  filepath.Join(tmp, "dank-data.duckdb"),
-		Client:     srv.Client(),
-		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Catalogs:  []string{srv.URL + "/catalog.json"},
+		CachePath: filepath.Join(tmp, "dank-data.duckdb"),
+		Client:    srv.Client(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
 	})
 	if err == nil {
 		t.Fatal("expected error")
@@ -172,10 +196,10 @@ func TestDownload_TTLSkip(t *testing.T) {
 	defer srv.Close()
 
 	path, err := Download(context.Background(), "us/ct", Options{
-		CatalogURL: srv.URL + "/catalog.json",
-		CachePath:  cachePath,
-		Client:     srv.Client(),
-		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Catalogs:  []string{srv.URL + "/catalog.json"},
+		CachePath: cachePath,
+		Client:    srv.Client(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
 	})
 	if err != nil {
 		t.Fatalf("Download: %v", err)
@@ -199,11 +223,11 @@ func TestDownload_TTLSkipBypassedByForce(t *testing.T) {
 	os.WriteFile(cachePath, []byte("prior-good"), 0o644)
 
 	_, err := Download(context.Background(), "us/ct", Options{
-		CatalogURL: srv.URL + "/catalog.json",
-		CachePath:  cachePath,
-		Client:     srv.Client(),
-		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
-		Force:      true,
+		Catalogs:  []string{srv.URL + "/catalog.json"},
+		CachePath: cachePath,
+		Client:    srv.Client(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Force:     true,
 	})
 	if err != nil {
 		t.Fatalf("Download: %v", err)
@@ -226,10 +250,10 @@ func TestDownload_StalePast7Days(t *testing.T) {
 	os.Chtimes(cachePath, old, old)
 
 	_, err := Download(context.Background(), "us/ct", Options{
-		CatalogURL: srv.URL + "/catalog.json",
-		CachePath:  cachePath,
-		Client:     srv.Client(),
-		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Catalogs:  []string{srv.URL + "/catalog.json"},
+		CachePath: cachePath,
+		Client:    srv.Client(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
 	})
 	if err != nil {
 		t.Fatalf("Download: %v", err)
@@ -257,10 +281,11 @@ func TestDownload_CatalogFailsWithStaleCache(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(&buf, nil))
 
 	path, err := Download(context.Background(), "us/ct", Options{
-		CatalogURL: srv.URL + "/catalog.json",
-		CachePath:  cachePath,
-		Client:     srv.Client(),
-		Logger:     logger,
+		Catalogs:  []string{srv.URL + "/catalog.json"},
+		CachePath: cachePath,
+		Client:    srv.Client(),
+		Retry:     &fastRetry,
+		Logger:    logger,
 	})
 	if err != nil {
 		t.Fatalf("expected fallback, got error: %v", err)
@@ -282,10 +307,11 @@ func TestDownload_CatalogFailsNoCache(t *testing.T) {
 
 	tmp := t.TempDir()
 	_, err := Download(context.Background(), "us/ct", Options{
-		CatalogURL: srv.URL + "/catalog.json",
-		CachePath:  filepath.Join(tmp, "dank-data.duckdb"),
-		Client:     srv.Client(),
-		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Catalogs:  []string{srv.URL + "/catalog.json"},
+		CachePath: filepath.Join(tmp, "dank-data.duckdb"),
+		Client:    srv.Client(),
+		Retry:     &fastRetry,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
 	})
 	if err == nil {
 		t.Fatal("expected error with no cache")
@@ -300,12 +326,102 @@ func TestDownload_RejectsUnsafeID(t *testing.T) {
 
 	tmp := t.TempDir()
 	_, err := Download(context.Background(), "../../etc/passwd", Options{
-		CatalogURL: srv.URL + "/catalog.json",
-		CachePath:  filepath.Join(tmp, "dank-data.duckdb"),
-		Client:     srv.Client(),
-		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Catalogs:  []string{srv.URL + "/catalog.json"},
+		CachePath: filepath.Join(tmp, "dank-data.duckdb"),
+		Client:    srv.Client(),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
 	})
 	if err == nil {
 		t.Fatal("expected validation error for unsafe id")
 	}
 }
+
+func TestDownload_RetriesTransientFailures(t *testing.T) {
+	compressed, shaHex, payload := buildSnapshot(t)
+	var snapshotFailures atomic.Int32
+	srv := startCatalogServer(t, shaHex, func(w http.ResponseWriter, r *http.Request) {
+		if snapshotFailures.Add(1) <= 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(compressed)
+	})
+	defer srv.Close()
+	// The catalog fails once before the snapshot fails twice
+	var catalogFailures atomic.Int32
+	flaky := srv.Config.Handler
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/catalog.json" && catalogFailures.Add(1) <= 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		flaky.ServeHTTP(w, r)
+	})
+
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+	_, err := Download(context.Background(), "us/ct", Options{
+		Catalogs:  []string{srv.URL + "/catalog.json"},
+		CachePath: cachePath,
+		Client:    srv.Client(),
+		Retry:     &fastRetry,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireInstalled(t, cachePath, payload)
+	if got := catalogFailures.Load(); got != 2 {
+		t.Errorf("%d catalog requests; want 2", got)
+	}
+	if got := snapshotFailures.Load(); got != 3 {
+		t.Errorf("%d snapshot requests; want 3", got)
+	}
+}
+
+func TestDownload_MultipleCatalogs(t *testing.T) {
+	compressed, shaHex, payload := buildSnapshot(t)
+	srv := startServer(t, compressed, shaHex) // lists us/ct
+	defer srv.Close()
+
+	// A local catalog of higher precedence lists us/ct too, and us/nv
+	dir := t.TempDir()
+	local := fmt.Sprintf(`{"version": 1, "datasets": {
+		"us/ct": {"title": "Local", "duckdb_url": "%[1]s/snapshot.zst", "sha256": "%[2]s"},
+		"us/nv": {"title": "Local only", "duckdb_url": "%[1]s/snapshot.zst", "sha256": "%[2]s"}}}`, srv.URL, shaHex)
+	if err := os.WriteFile(filepath.Join(dir, "catalog.json"), []byte(local), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	localURL, err := catalog.Location(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalogs := []string{localURL, srv.URL + "/catalog.json"}
+
+	download := func(id string) (string, error) {
+		cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+		_, err := Download(context.Background(), id, Options{
+			Catalogs:  catalogs,
+			CachePath: cachePath,
+			Client:    srv.Client(),
+			Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		})
+		return cachePath, err
+	}
+
+	// Found in the first catalog listing it
+	cachePath, err := download("us/ct")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireInstalled(t, cachePath, payload)
+	if manifest, _ := ReadManifest(cachePath); manifest.CatalogURL != localURL {
+		t.Errorf("catalog_url = %q; want %q", manifest.CatalogURL, localURL)
+	}
+
+	// Unknown ids are reported with the ids of every catalog
+	_, err = download("us/zz")
+	if err == nil || !strings.Contains(err.Error(), "us/ct") || !strings.Contains(err.Error(), "us/nv") {
+		t.Errorf("expected error listing known ids, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/AgentDank/dank-mcp/internal/catalog"
+)
+
+// copyVerified copies the snapshot at fileURL, a file:// URL, to
+// partialPath, verifying its sha256 as downloadVerified does. The copy is
+// conditional on the file having been modified since prev was recorded,
+// returning catalog.ErrNotModified if it has not. Returns the validators
+// of the copied snapshot, its modification time as Last-Modified.
+func copyVerified(fileURL, partialPath, sha256Hex string, prev catalog.Validators) (catalog.Validators, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return catalog.Validators{}, fmt.Errorf("invalid snapshot URL %q: %w", fileURL, err)
+	}
+	src, err := os.Open(catalog.FilePath(u))
+	if err != nil {
+		return catalog.Validators{}, fmt.Errorf("open snapshot: %w", err)
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return catalog.Validators{}, fmt.Errorf("stat snapshot: %w", err)
+	}
+	validators := catalog.Validators{LastModified: info.ModTime().UTC().Format(http.TimeFormat)}
+	if prev.LastModified != "" && prev == validators {
+		return prev, catalog.ErrNotModified
+	}
+	if info.Size() > maxDownloadSize {
+		return catalog.Validators{}, fmt.Errorf("snapshot %s too large (%d bytes)", fileURL, info.Size())
+	}
+
+	// A local copy is never resumed, so any partial download is replaced
+	removePartial(partialPath)
+	dst, err := os.Create(partialPath)
+	if err != nil {
+		return catalog.Validators{}, fmt.Errorf("create partial: %w", err)
+	}
+	defer dst.Close()
+
+	reporter := newProgressReporter(info.Size())
+	defer reporter.finish()
+
+	if _, err := copyAndVerify(dst, reporter.wrap(io.LimitReader(src, maxDownloadSize)), sha256Hex); err != nil {
+		if errors.Is(err, errSHA256Mismatch) {
+			removePartial(partialPath)
+		}
+		return catalog.Validators{}, err
+	}
+	return validators, nil
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package fetch
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AgentDank/dank-mcp/internal/catalog"
+	"github.com/AgentDank/dank-mcp/internal/version"
+)
+
+// writeLocalCatalog writes a catalog directory, as on a USB drive, listing
+// us/ct with sha256Hex and a relative duckdb_url, and its snapshot.
+// Returns the catalog's location.
+func writeLocalCatalog(t *testing.T, compressed []byte, sha256Hex string) string {
+	t.Helper()
+	dir := t.TempDir()
+	body := fmt.Sprintf(`{"version": 1, "datasets": {"us/ct": {
+		"title": "Test", "duckdb_url": "us/ct/dank-data.duckdb.zst", "sha256": "%s"}}}`, sha256Hex)
+	if err := os.WriteFile(filepath.Join(dir, "catalog.json"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	snapshotPath := filepath.Join(dir, "us", "ct", "dank-data.duckdb.zst")
+	if err := os.MkdirAll(filepath.Dir(snapshotPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(snapshotPath, compressed, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	location, err := catalog.Location(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
+
+func downloadLocal(location, cachePath string) error {
+	_, err := Download(context.Background(), "us/ct", Options{
+		Catalogs:  []string{location},
+		CachePath: cachePath,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	return err
+}
+
+func TestDownload_LocalCatalog(t *testing.T) {
+	compressed, shaHex, payload := buildSnapshot(t)
+	location := writeLocalCatalog(t, compressed, shaHex)
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+
+	if err := downloadLocal(location, cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireInstalled(t, cachePath, payload)
+	manifest, err := ReadManifest(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantURL := strings.TrimSuffix(location, "catalog.json") + "us/ct/dank-data.duckdb.zst"
+	if manifest.URL != wantURL || manifest.CatalogURL != location || manifest.Snapshot.LastModified == "" {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+
+	// Past its TTL, the unchanged catalog file keeps the cache
+	expireCache(t, cachePath)
+	if err := downloadLocal(location, cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireFresh(t, cachePath)
+}
+
+func TestDownload_LocalSHA256Mismatch(t *testing.T) {
+	compressed, _, _ := buildSnapshot(t)
+	location := writeLocalCatalog(t, compressed, "0000000000000000000000000000000000000000000000000000000000000000")
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+
+	if err := downloadLocal(location, cachePath); err == nil {
+		t.Fatal("expected sha256 mismatch")
+	}
+	for _, path := range []string{cachePath, cachePath + ".zst.partial"} {
+		if _, err := os.Stat(path); err == nil {
+			t.Errorf("%s should not exist after mismatch", path)
+		}
+	}
+}
+
+// signCatalog writes a minisign signature of the catalog file at location,
+// by a new key, returning a keyring of that key.
+func signCatalog(t *testing.T, location string) catalog.Keyring {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := catalog.PublicKey{ID: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}, Key: pub}
+	path := strings.TrimPrefix(location, "file://")
+	body, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := ed25519.Sign(priv, body)
+	trustedComment := "file:catalog.json"
+	globalSig := ed25519.Sign(priv, append(append([]byte{}, signature...), trustedComment...))
+	raw := append(append([]byte("Ed"), key.ID[:]...), signature...)
+	sig := fmt.Sprintf("untrusted comment: test\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw), trustedComment, base64.StdEncoding.EncodeToString(globalSig))
+	if err := os.WriteFile(path+catalog.SignatureSuffix, []byte(sig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return catalog.Keyring{key}
+}
+
+func TestDownload_SignedCatalog(t *testing.T) {
+	compressed, shaHex, payload := buildSnapshot(t)
+	location := writeLocalCatalog(t, compressed, shaHex)
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+	downloadSigned := func(keys catalog.Keyring) error {
+		_, err := Download(context.Background(), "us/ct", Options{
+			Catalogs:    []string{location},
+			CatalogKeys: keys,
+			CachePath:   cachePath,
+			Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		})
+		return err
+	}
+
+	if err := downloadSigned(catalog.Keyring{}); !errors.Is(err, catalog.ErrUnverified) {
+		t.Fatalf("unsigned catalog: expected ErrUnverified, got %v", err)
+	}
+	keys := signCatalog(t, location)
+	if err := downloadSigned(keys); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireInstalled(t, cachePath, payload)
+	manifest, err := ReadManifest(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.CatalogKeyID != keys[0].String() {
+		t.Errorf("CatalogKeyID = %q; want %q", manifest.CatalogKeyID, keys[0])
+	}
+}
+
+func TestDownload_CatalogV2(t *testing.T) {
+	compressed, shaHex, payload := buildSnapshot(t)
+	location := writeLocalCatalog(t, compressed, shaHex)
+	body := fmt.Sprintf(`{"version": 2, "datasets": {"us/ct": {
+		"title": "Test", "duckdb_url": "us/ct/dank-data.duckdb.zst", "sha256": "%s",
+		"compressed_size": %d, "uncompressed_size": %d,
+		"attribution": "CT Open Data", "license": "CC0-1.0", "jurisdiction": "US-CT", "tags": ["retail"],
+		"tables": [{"name": "brands", "description": "Registered brands"}],
+		"min_version": "v0.5.0", "bindings_url": "us/ct/bindings.json"}}}`, shaHex, len(compressed), len(payload))
+	if err := os.WriteFile(strings.TrimPrefix(location, "file://"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldVersion := version.Version
+	t.Cleanup(func() { version.Version = oldVersion })
+	version.Version = "0.4.0"
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+	if err := downloadLocal(location, cachePath); err == nil || !strings.Contains(err.Error(), "requires dank-mcp v0.5.0") {
+		t.Fatalf("expected min_version error, got %v", err)
+	}
+
+	version.Version = "0.5.0"
+	if err := downloadLocal(location, cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireInstalled(t, cachePath, payload)
+	manifest, err := ReadManifest(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBindings := strings.TrimSuffix(location, "catalog.json") + "us/ct/bindings.json"
+	if manifest.Title != "Test" || manifest.License != "CC0-1.0" || manifest.CompressedSize != int64(len(compressed)) ||
+		len(manifest.Tables) != 1 || manifest.Tables[0].Name != "brands" || manifest.BindingsURL != wantBindings {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+}
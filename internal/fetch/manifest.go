@@ -0,0 +1,94 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package fetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AgentDank/dank-mcp/internal/catalog"
+)
+
+// Manifest records the provenance of an installed snapshot: the dataset,
+// the catalog and snapshot it was installed from, and when. It is written
+// next to the snapshot on install, so that an answer can be traced to the
+// exact snapshot, and later checks can be conditional requests that skip
+// a snapshot whose sha256 is unchanged in the catalog.
+type Manifest struct {
+	// ID is the dataset id.
+	ID string `json:"id"`
+
+	// Title, Description and Metadata describe the dataset, as its catalog
+	// entry did when it was last checked.
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	catalog.Metadata
+
+	// SHA256 is the catalog's sha256 of the installed snapshot, and
+	// UpdatedAt its updated_at.
+	SHA256    string `json:"sha256"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+
+	// URL is the snapshot's duckdb_url and Snapshot its validators.
+	URL      string             `json:"url"`
+	Snapshot catalog.Validators `json:"snapshot"`
+
+	// CatalogURL is the catalog last checked for this dataset, and Catalog
+	// its validators at that time.
+	CatalogURL string             `json:"catalog_url"`
+	Catalog    catalog.Validators `json:"catalog"`
+
+	// CatalogKeyID is the id of the key the catalog's signature was
+	// verified with, or empty if it was not verified.
+	CatalogKeyID string `json:"catalog_key_id,omitempty"`
+
+	// DuckDBSHA256 is the sha256 of the installed, decompressed DuckDB,
+	// to verify it against later.
+	DuckDBSHA256 string `json:"duckdb_sha256,omitempty"`
+
+	// DownloadedAt is when the snapshot was installed.
+	DownloadedAt time.Time `json:"downloaded_at"`
+}
+
+// ManifestPath returns the path of the manifest of the snapshot at cachePath.
+func ManifestPath(cachePath string) string {
+	return cachePath + ".meta.json"
+}
+
+// ReadManifest reads the manifest of the snapshot at cachePath.
+func ReadManifest(cachePath string) (Manifest, error) {
+	var manifest Manifest
+	b, err := os.ReadFile(ManifestPath(cachePath))
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return manifest, fmt.Errorf("parse manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// writeManifest atomically writes the manifest of the snapshot at cachePath.
+func writeManifest(cachePath string, manifest Manifest) error {
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := ManifestPath(cachePath)
+	if err := os.WriteFile(path+".new", b, 0o644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := os.Rename(path+".new", path); err != nil {
+		os.Remove(path + ".new")
+		return fmt.Errorf("install manifest: %w", err)
+	}
+	return nil
+}
+
+// touchCache marks the snapshot at cachePath as fresh, restarting its TTL.
+func touchCache(cachePath string) error {
+	now := time.Now()
+	return os.Chtimes(cachePath, now, now)
+}
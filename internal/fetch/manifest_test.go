@@ -0,0 +1,190 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package fetch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// versionedServer serves a catalog and snapshot that can be replaced, with
+// ETags so that requests can be conditional, and counts full responses.
+type versionedServer struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	compressed   []byte
+	sha256Hex    string
+	catalogGets  int // catalog responses with a body
+	snapshotGets int // snapshot responses with a body
+}
+
+func newVersionedServer(t *testing.T, compressed []byte, sha256Hex string) *versionedServer {
+	t.Helper()
+	vs := &versionedServer{compressed: compressed, sha256Hex: sha256Hex}
+	vs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vs.mu.Lock()
+		defer vs.mu.Unlock()
+		var body []byte
+		switch r.URL.Path {
+		case "/catalog.json":
+			body = []byte(fmt.Sprintf(`{"version": 1, "datasets": {"us/ct": {
+				"title": "Test", "duckdb_url": "http://%s/snapshot.zst", "sha256": "%s"}}}`,
+				r.Host, vs.sha256Hex))
+		case "/snapshot.zst":
+			body = vs.compressed
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		etag := fmt.Sprintf(`"%s-%s"`, strings.TrimPrefix(r.URL.Path, "/"), vs.sha256Hex[:8])
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if r.URL.Path == "/catalog.json" {
+			vs.catalogGets++
+		} else {
+			vs.snapshotGets++
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+	}))
+	t.Cleanup(vs.Close)
+	return vs
+}
+
+func (vs *versionedServer) update(compressed []byte, sha256Hex string) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.compressed, vs.sha256Hex = compressed, sha256Hex
+}
+
+func (vs *versionedServer) counts() (catalogGets, snapshotGets int) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	return vs.catalogGets, vs.snapshotGets
+}
+
+// expireCache ages the cache at cachePath past its TTL.
+func expireCache(t *testing.T, cachePath string) {
+	t.Helper()
+	ageCache(t, cachePath, 8*24*time.Hour)
+}
+
+// ageCache sets the modification time of the cache at cachePath to age ago.
+func ageCache(t *testing.T, cachePath string, age time.Duration) {
+	t.Helper()
+	old := time.Now().Add(-age)
+	if err := os.Chtimes(cachePath, old, old); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func requireFresh(t *testing.T, cachePath string) {
+	t.Helper()
+	info, err := os.Stat(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if age := time.Since(info.ModTime()); age > time.Hour {
+		t.Errorf("cache not touched; age %s", age)
+	}
+}
+
+func TestDownload_CatalogNotModified(t *testing.T) {
+	compressed, shaHex, payload := buildSnapshot(t)
+	srv := newVersionedServer(t, compressed, shaHex)
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+
+	if err := downloadTo(t, srv.URL, srv.Client(), cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	manifest, err := ReadManifest(cachePath)
+	if err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if sum := sha256.Sum256(payload); manifest.DuckDBSHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("manifest duckdb_sha256 %q does not match the installed file", manifest.DuckDBSHA256)
+	}
+	if manifest.ID != "us/ct" || manifest.SHA256 != shaHex || manifest.URL != srv.URL+"/snapshot.zst" ||
+		manifest.CatalogURL != srv.URL+"/catalog.json" || time.Since(manifest.DownloadedAt) > time.Minute ||
+		manifest.Catalog.ETag == "" || manifest.Snapshot.ETag == "" {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+
+	// Past the TTL, the catalog is answered 304 and the cache is kept
+	expireCache(t, cachePath)
+	if err := downloadTo(t, srv.URL, srv.Client(), cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if catalogGets, snapshotGets := srv.counts(); catalogGets != 1 || snapshotGets != 1 {
+		t.Errorf("catalog gets %d, snapshot gets %d; want 1, 1", catalogGets, snapshotGets)
+	}
+	requireFresh(t, cachePath)
+	requireInstalled(t, cachePath, payload)
+}
+
+func TestDownload_SHA256UnchangedSkipsDownload(t *testing.T) {
+	compressed, shaHex, payload := buildSnapshot(t)
+	srv := newVersionedServer(t, compressed, shaHex)
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+
+	if err := downloadTo(t, srv.URL, srv.Client(), cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	// A catalog without validators is fetched, but lists the same snapshot
+	manifest, _ := ReadManifest(cachePath)
+	manifest.Catalog.ETag = ""
+	if err := writeManifest(cachePath, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	expireCache(t, cachePath)
+	if err := downloadTo(t, srv.URL, srv.Client(), cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if catalogGets, snapshotGets := srv.counts(); catalogGets != 2 || snapshotGets != 1 {
+		t.Errorf("catalog gets %d, snapshot gets %d; want 2, 1", catalogGets, snapshotGets)
+	}
+	requireFresh(t, cachePath)
+	requireInstalled(t, cachePath, payload)
+	if manifest, _ := ReadManifest(cachePath); manifest.Catalog.ETag == "" {
+		t.Error("catalog validators not recorded")
+	}
+}
+
+func TestDownload_SHA256ChangedDownloads(t *testing.T) {
+	compressed, shaHex, _ := buildSnapshot(t)
+	srv := newVersionedServer(t, compressed, shaHex)
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+
+	if err := downloadTo(t, srv.URL, srv.Client(), cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	newCompressed, newSHA, newPayload := buildSnapshotOf(t, []byte("newer fake duckdb bytes"))
+	srv.update(newCompressed, newSHA)
+
+	expireCache(t, cachePath)
+	if err := downloadTo(t, srv.URL, srv.Client(), cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if _, snapshotGets := srv.counts(); snapshotGets != 2 {
+		t.Errorf("snapshot gets %d; want 2", snapshotGets)
+	}
+	requireInstalled(t, cachePath, newPayload)
+	if manifest, _ := ReadManifest(cachePath); manifest.SHA256 != newSHA {
+		t.Errorf("manifest sha256 %q; want %q", manifest.SHA256, newSHA)
+	}
+}
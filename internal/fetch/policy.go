@@ -0,0 +1,46 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package fetch
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultMaxAge is how long an installed snapshot is used, under
+// RefreshTTL, before the catalog is checked for a newer one.
+const DefaultMaxAge = 7 * 24 * time.Hour
+
+// RefreshPolicy decides when Download checks for a newer snapshot than
+// the one installed.
+type RefreshPolicy string
+
+const (
+	// RefreshTTL checks the catalog once the installed snapshot is older
+	// than MaxAge, and downloads only if its sha256 has changed.
+	RefreshTTL RefreshPolicy = "ttl"
+
+	// RefreshCatalog checks the catalog every time, and downloads only if
+	// the snapshot's sha256 has changed.
+	RefreshCatalog RefreshPolicy = "catalog"
+
+	// RefreshNever uses an installed snapshot, however old, without any
+	// network I/O. A snapshot is only downloaded if none is installed.
+	RefreshNever RefreshPolicy = "never"
+
+	// RefreshAlways downloads the snapshot every time.
+	RefreshAlways RefreshPolicy = "always"
+)
+
+// RefreshPolicies lists the valid RefreshPolicy values.
+var RefreshPolicies = []RefreshPolicy{RefreshTTL, RefreshCatalog, RefreshNever, RefreshAlways}
+
+// ParseRefreshPolicy returns the RefreshPolicy named s.
+func ParseRefreshPolicy(s string) (RefreshPolicy, error) {
+	for _, policy := range RefreshPolicies {
+		if string(policy) == s {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown refresh policy %q; expected one of %v", s, RefreshPolicies)
+}
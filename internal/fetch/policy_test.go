@@ -0,0 +1,93 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package fetch
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDownload_RefreshPolicies(t *testing.T) {
+	tests := []struct {
+		name         string
+		refresh      RefreshPolicy
+		maxAge       time.Duration
+		age          time.Duration // of the installed snapshot
+		updated      bool          // whether the catalog lists a new snapshot
+		wantCatalog  int           // catalog responses after the install
+		wantSnapshot int           // snapshot responses after the install
+	}{
+		{"ttl fresh", RefreshTTL, 0, 24 * time.Hour, true, 0, 0},
+		{"ttl default", "", 0, 8 * 24 * time.Hour, true, 1, 1},
+		{"ttl short max age", RefreshTTL, time.Hour, 2 * time.Hour, true, 1, 1},
+		{"catalog unchanged", RefreshCatalog, 0, time.Minute, false, 1, 0},
+		{"catalog changed", RefreshCatalog, 0, time.Minute, true, 1, 1},
+		{"never", RefreshNever, 0, 365 * 24 * time.Hour, true, 0, 0},
+		{"always", RefreshAlways, 0, time.Minute, false, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, shaHex, payload := buildSnapshot(t)
+			srv := newVersionedServer(t, compressed, shaHex)
+			cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+			if err := downloadTo(t, srv.URL, srv.Client(), cachePath); err != nil {
+				t.Fatalf("install: %v", err)
+			}
+			ageCache(t, cachePath, tt.age)
+			if tt.updated {
+				compressed, shaHex, payload = buildSnapshotOf(t, []byte("newer fake duckdb bytes"))
+				srv.update(compressed, shaHex)
+			}
+			// Counts do not include 304 responses, so drop catalog validators
+			manifest, _ := ReadManifest(cachePath)
+			manifest.Catalog.ETag = ""
+			writeManifest(cachePath, manifest)
+
+			_, err := Download(context.Background(), "us/ct", Options{
+				Catalogs:  []string{srv.URL + "/catalog.json"},
+				CachePath: cachePath,
+				Client:    srv.Client(),
+				Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+				Refresh:   tt.refresh,
+				MaxAge:    tt.maxAge,
+			})
+			if err != nil {
+				t.Fatalf("Download: %v", err)
+			}
+			catalogGets, snapshotGets := srv.counts()
+			if catalogGets-1 != tt.wantCatalog || snapshotGets-1 != tt.wantSnapshot {
+				t.Errorf("catalog gets %d, snapshot gets %d; want %d, %d",
+					catalogGets-1, snapshotGets-1, tt.wantCatalog, tt.wantSnapshot)
+			}
+			if tt.wantSnapshot > 0 || !tt.updated {
+				requireInstalled(t, cachePath, payload)
+			}
+		})
+	}
+}
+
+func TestDownload_RejectsUnknownRefreshPolicy(t *testing.T) {
+	_, err := Download(context.Background(), "us/ct", Options{
+		CachePath: filepath.Join(t.TempDir(), "dank-data.duckdb"),
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Refresh:   "sometimes",
+	})
+	if err == nil {
+		t.Fatal("expected error for unknown refresh policy")
+	}
+}
+
+func TestParseRefreshPolicy(t *testing.T) {
+	for _, policy := range RefreshPolicies {
+		if got, err := ParseRefreshPolicy(string(policy)); err != nil || got != policy {
+			t.Errorf("ParseRefreshPolicy(%q) = %q, %v", policy, got, err)
+		}
+	}
+	if _, err := ParseRefreshPolicy("daily"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package fetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// partialMeta is the sidecar of a partial download, recording what the
+// partial file is a prefix of, so the download can be resumed.
+type partialMeta struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+	ETag   string `json:"etag,omitempty"`
+}
+
+// partialMetaPath returns the path of the sidecar of partialPath.
+func partialMetaPath(partialPath string) string {
+	return partialPath + ".json"
+}
+
+// readPartialMeta reads the sidecar of partialPath.
+func readPartialMeta(partialPath string) (partialMeta, error) {
+	var meta partialMeta
+	b, err := os.ReadFile(partialMetaPath(partialPath))
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return meta, fmt.Errorf("parse partial sidecar: %w", err)
+	}
+	return meta, nil
+}
+
+// writePartialMeta writes the sidecar of partialPath.
+func writePartialMeta(partialPath string, meta partialMeta) error {
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(partialMetaPath(partialPath), b, 0o644); err != nil {
+		return fmt.Errorf("write partial sidecar: %w", err)
+	}
+	return nil
+}
+
+// removePartial removes a partial download and its sidecar.
+func removePartial(partialPath string) {
+	os.Remove(partialPath)
+	os.Remove(partialMetaPath(partialPath))
+}
+
+// hashFile writes the contents of the file at path into h.
+// Returns the number of bytes hashed.
+func hashFile(path string, h hash.Hash) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return io.Copy(h, f)
+}
+
+// fileSize returns the size of the file at path, or 0 if it cannot be
+// stat'ed, such as when there is no partial download yet.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// isStrongETag returns whether etag is a strong validator, which is
+// required for If-Range.
+func isStrongETag(etag string) bool {
+	return etag != "" && !strings.HasPrefix(etag, "W/")
+}
+
+// contentRangeStart returns the first byte position of resp's Content-Range,
+// e.g. 100 for "bytes 100-199/200", or -1 if it is missing or malformed.
+func contentRangeStart(resp *http.Response) int64 {
+	spec, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return -1
+	}
+	first, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return -1
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return start
+}
@@ -0,0 +1,290 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AgentDank/dank-mcp/internal/retry"
+)
+
+// bigSnapshot returns a compressed snapshot large enough to be split, its
+// sha256, and its payload.
+func bigSnapshot(t *testing.T) ([]byte, string, []byte) {
+	t.Helper()
+	compressed, shaHex, payload := buildSnapshot(t)
+	if len(compressed) < 8 {
+		t.Fatalf("snapshot too small to split: %d bytes", len(compressed))
+	}
+	return compressed, shaHex, payload
+}
+
+// writePartial leaves a partial download of the first n bytes of compressed
+// at cachePath, as an interrupted attempt would, with a sidecar for etag.
+func writePartial(t *testing.T, cachePath string, compressed []byte, n int, url, shaHex, etag string) {
+	t.Helper()
+	partialPath := cachePath + ".zst.partial"
+	if err := os.WriteFile(partialPath, compressed[:n], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePartialMeta(partialPath, partialMeta{URL: url, SHA256: shaHex, ETag: etag}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rangeRecorder records the Range headers of snapshot requests.
+type rangeRecorder struct {
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (r *rangeRecorder) record(req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ranges = append(r.ranges, req.Header.Get("Range"))
+}
+
+func (r *rangeRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.ranges...)
+}
+
+func downloadTo(t *testing.T, srvURL string, client *http.Client, cachePath string) error {
+	t.Helper()
+	return downloadWithRetry(t, srvURL, client, cachePath, nil)
+}
+
+func downloadWithRetry(t *testing.T, srvURL string, client *http.Client, cachePath string, policy *retry.Policy) error {
+	t.Helper()
+	_, err := Download(context.Background(), "us/ct", Options{
+		Catalogs:  []string{srvURL + "/catalog.json"},
+		CachePath: cachePath,
+		Client:    client,
+		Retry:     policy,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	return err
+}
+
+func requireInstalled(t *testing.T, cachePath string, payload []byte) {
+	t.Helper()
+	got, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatalf("read cache: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Errorf("cached bytes mismatch: got %q want %q", got, payload)
+	}
+	for _, leftover := range []string{".zst.partial", ".zst.partial.json"} {
+		if _, err := os.Stat(cachePath + leftover); err == nil {
+			t.Errorf("%s left behind after install", leftover)
+		}
+	}
+}
+
+func TestDownload_ResumesPartial(t *testing.T) {
+	compressed, shaHex, payload := bigSnapshot(t)
+	var rec rangeRecorder
+	srv := startCatalogServer(t, shaHex, func(w http.ResponseWriter, r *http.Request) {
+		rec.record(r)
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "snapshot.zst", time.Time{}, bytes.NewReader(compressed))
+	})
+	defer srv.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+	half := len(compressed) / 2
+	writePartial(t, cachePath, compressed, half, srv.URL+"/snapshot.zst", shaHex, `"v1"`)
+
+	if err := downloadTo(t, srv.URL, srv.Client(), cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireInstalled(t, cachePath, payload)
+	if got, want := rec.get(), []string{fmt.Sprintf("bytes=%d-", half)}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("ranges = %q; want %q", got, want)
+	}
+}
+
+func TestDownload_ResumeRestartsWhenETagChanged(t *testing.T) {
+	compressed, shaHex, payload := bigSnapshot(t)
+	var rec rangeRecorder
+	srv := startCatalogServer(t, shaHex, func(w http.ResponseWriter, r *http.Request) {
+		rec.record(r)
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "snapshot.zst", time.Time{}, bytes.NewReader(compressed))
+	})
+	defer srv.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+	// The prefix is garbage from an older snapshot; If-Range gets the whole new one
+	stale := append([]byte("stale"), compressed[5:]...)
+	writePartial(t, cachePath, stale, len(compressed)/2, srv.URL+"/snapshot.zst", shaHex, `"v1"`)
+
+	if err := downloadTo(t, srv.URL, srv.Client(), cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireInstalled(t, cachePath, payload)
+}
+
+func TestDownload_ResumeRestartsWhenRangeIgnored(t *testing.T) {
+	compressed, shaHex, payload := bigSnapshot(t)
+	srv := startServer(t, compressed, shaHex) // always sends 200 with the whole snapshot
+	defer srv.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+	writePartial(t, cachePath, compressed, len(compressed)/2, srv.URL+"/snapshot.zst", shaHex, "")
+
+	if err := downloadTo(t, srv.URL, srv.Client(), cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireInstalled(t, cachePath, payload)
+}
+
+func TestDownload_ResumeCompletedPartial(t *testing.T) {
+	compressed, shaHex, payload := bigSnapshot(t)
+	srv := startCatalogServer(t, shaHex, func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "snapshot.zst", time.Time{}, bytes.NewReader(compressed))
+	})
+	defer srv.Close()
+
+	// A complete download that was never installed gets 416 and is verified as-is
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+	writePartial(t, cachePath, compressed, len(compressed), srv.URL+"/snapshot.zst", shaHex, "")
+
+	if err := downloadTo(t, srv.URL, srv.Client(), cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireInstalled(t, cachePath, payload)
+}
+
+// startDroppingServer returns a server of compressed that drops the
+// connection halfway through the body of the first snapshot request.
+func startDroppingServer(t *testing.T, compressed []byte, shaHex string, rec *rangeRecorder) *httptest.Server {
+	t.Helper()
+	half := len(compressed) / 2
+	var failFirst sync.Once
+	return startCatalogServer(t, shaHex, func(w http.ResponseWriter, r *http.Request) {
+		rec.record(r)
+		dropped := false
+		failFirst.Do(func() {
+			// Send half the snapshot, then drop the connection
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(compressed)))
+			w.Write(compressed[:half])
+			w.(http.Flusher).Flush()
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			dropped = true
+		})
+		if !dropped {
+			http.ServeContent(w, r, "snapshot.zst", time.Time{}, bytes.NewReader(compressed))
+		}
+	})
+}
+
+func TestDownload_KeepsPartialForResume(t *testing.T) {
+	compressed, shaHex, payload := bigSnapshot(t)
+	half := len(compressed) / 2
+	var rec rangeRecorder
+	srv := startDroppingServer(t, compressed, shaHex, &rec)
+	defer srv.Close()
+
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+	if err := downloadWithRetry(t, srv.URL, srv.Client(), cachePath, &retry.Policy{Attempts: 1}); err == nil {
+		t.Fatal("expected error from dropped connection")
+	}
+	if info, err := os.Stat(cachePath + ".zst.partial"); err != nil || info.Size() != int64(half) {
+		t.Fatalf("partial download not kept: %v, %v", info, err)
+	}
+	if _, err := readPartialMeta(cachePath + ".zst.partial"); err != nil {
+		t.Fatalf("partial sidecar not kept: %v", err)
+	}
+
+	if err := downloadTo(t, srv.URL, srv.Client(), cachePath); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireInstalled(t, cachePath, payload)
+	if got := rec.get(); len(got) != 2 || got[1] != fmt.Sprintf("bytes=%d-", half) {
+		t.Errorf("ranges = %q", got)
+	}
+}
+
+// startTricklingServer serves a catalog whose snapshot is sent step bytes
+// at a time, dropping the connection after each step until the last.
+func startTricklingServer(t *testing.T, compressed []byte, shaHex string, step int, rec *rangeRecorder) *httptest.Server {
+	t.Helper()
+	return startCatalogServer(t, shaHex, func(w http.ResponseWriter, r *http.Request) {
+		rec.record(r)
+		var offset int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &offset)
+		if len(compressed)-offset <= step {
+			http.ServeContent(w, r, "snapshot.zst", time.Time{}, bytes.NewReader(compressed))
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(compressed)-offset))
+		if offset > 0 {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(compressed)-1, len(compressed)))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		w.Write(compressed[offset : offset+step])
+		w.(http.Flusher).Flush()
+		if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			conn.Close()
+		}
+	})
+}
+
+func TestDownload_InterruptionsWithProgressDoNotUseUpAttempts(t *testing.T) {
+	compressed, shaHex, payload := bigSnapshot(t)
+	step := max(len(compressed)/8, 1)
+	var rec rangeRecorder
+	srv := startTricklingServer(t, compressed, shaHex, step, &rec)
+	defer srv.Close()
+
+	// Interrupted more times than fastRetry has attempts, but never stalled
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+	if err := downloadWithRetry(t, srv.URL, srv.Client(), cachePath, &fastRetry); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireInstalled(t, cachePath, payload)
+	if got := len(rec.get()); got <= fastRetry.Attempts {
+		t.Errorf("%d snapshot requests; want more than %d", got, fastRetry.Attempts)
+	}
+}
+
+func TestDefaultClient_NoOverallTimeout(t *testing.T) {
+	transport, ok := defaultClient.Transport.(*http.Transport)
+	if defaultClient.Timeout != 0 || !ok || transport.ResponseHeaderTimeout == 0 {
+		t.Errorf("snapshot client: Timeout %v, transport %T; want no Timeout and a ResponseHeaderTimeout", defaultClient.Timeout, defaultClient.Transport)
+	}
+}
+
+func TestDownload_ResumesAfterDroppedConnection(t *testing.T) {
+	compressed, shaHex, payload := bigSnapshot(t)
+	var rec rangeRecorder
+	srv := startDroppingServer(t, compressed, shaHex, &rec)
+	defer srv.Close()
+
+	// The same run resumes the interrupted body with a Range request
+	cachePath := filepath.Join(t.TempDir(), "dank-data.duckdb")
+	if err := downloadWithRetry(t, srv.URL, srv.Client(), cachePath, &fastRetry); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	requireInstalled(t, cachePath, payload)
+	if got := rec.get(); len(got) != 2 || got[0] != "" || got[1] != fmt.Sprintf("bytes=%d-", len(compressed)/2) {
+		t.Errorf("ranges = %q", got)
+	}
+}
@@ -5,15 +5,25 @@ package fetch
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 )
 
+// errSHA256Mismatch is wrapped by errors for downloads with the wrong digest.
+var errSHA256Mismatch = errors.New("sha256 mismatch")
+
 // copyAndVerify streams src into dst while computing a sha256. After EOF,
 // the computed digest is compared against wantHex. Returns the number of
 // bytes copied, or an error if the digest does not match.
 func copyAndVerify(dst io.Writer, src io.Reader, wantHex string) (int64, error) {
-	h := sha256.New()
+	return copyAndVerifyHash(dst, src, sha256.New(), wantHex)
+}
+
+// copyAndVerifyHash is copyAndVerify continuing from h, which may already
+// hold a prefix of the data, e.g. of a resumed download.
+func copyAndVerifyHash(dst io.Writer, src io.Reader, h hash.Hash, wantHex string) (int64, error) {
 	tee := io.TeeReader(src, h)
 	n, err := io.Copy(dst, tee)
 	if err != nil {
@@ -21,7 +31,7 @@ func copyAndVerify(dst io.Writer, src io.Reader, wantHex string) (int64, error)
 	}
 	got := hex.EncodeToString(h.Sum(nil))
 	if got != wantHex {
-		return n, fmt.Errorf("sha256 mismatch: expected %s, got %s", wantHex, got)
+		return n, fmt.Errorf("%w: expected %s, got %s", errSHA256Mismatch, wantHex, got)
 	}
 	return n, nil
 }
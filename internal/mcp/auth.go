@@ -0,0 +1,170 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// APIKey is a credential accepted by the HTTP transports, sent either as
+// "Authorization: Bearer <key>" or as an "X-API-Key: <key>" header.
+type APIKey struct {
+	Name string // Identity of the key's holder, logged with their requests
+	Key  string // The secret
+}
+
+// apiKeyNamePattern matches API key names.
+var apiKeyNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.@-]{1,64}$`)
+
+// ParseAPIKeys parses API keys separated by newlines or commas. Each is
+// either "name:key" or a bare key, which is named by its fingerprint.
+// Blank entries and lines starting with # are ignored, but text with no
+// keys at all is an error, so that authentication is never silently off.
+func ParseAPIKeys(text string) ([]APIKey, error) {
+	var keys []APIKey
+	names := make(map[string]bool)
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, entry := range strings.Split(line, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			var key APIKey
+			if name, secret, ok := strings.Cut(entry, ":"); ok {
+				key = APIKey{Name: strings.TrimSpace(name), Key: strings.TrimSpace(secret)}
+				if !apiKeyNamePattern.MatchString(key.Name) {
+					return nil, fmt.Errorf("invalid API key name %q: must match %s", key.Name, apiKeyNamePattern.String())
+				}
+			} else {
+				key = APIKey{Name: apiKeyFingerprint(entry), Key: entry}
+			}
+			if key.Key == "" {
+				return nil, fmt.Errorf("API key %q is empty", key.Name)
+			}
+			if names[key.Name] {
+				return nil, fmt.Errorf("duplicate API key name %q", key.Name)
+			}
+			names[key.Name] = true
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no API keys found")
+	}
+	return keys, nil
+}
+
+// LoadAPIKeys reads API keys from the file at path, in ParseAPIKeys format.
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+	keys, err := ParseAPIKeys(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// CheckAuth returns an error if config would serve the SSE or HTTP
+// transport without APIKeys on an address that other hosts can reach,
+// unless InsecureNoAuth is set. Without keys, a server listening only on a
+// loopback address, such as 127.0.0.1:8889 or localhost:8889, is allowed.
+func (config Config) CheckAuth() error {
+	if config.Transport == "" || config.Transport == TransportStdio || len(config.APIKeys) > 0 || config.InsecureNoAuth {
+		return nil
+	}
+	if isLoopbackHostPort(config.HostPort) {
+		return nil
+	}
+	return fmt.Errorf("the %s transport on %q would let anyone who can reach it query the database without an API key",
+		config.Transport, config.HostPort)
+}
+
+// isLoopbackHostPort returns whether hostPort names a loopback address. An
+// empty host, as in ":8889", listens on every interface, so is not.
+func isLoopbackHostPort(hostPort string) bool {
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// apiKeyFingerprint names a bare key by a prefix of its SHA-256, so it can
+// be attributed in logs without revealing it.
+func apiKeyFingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "key-" + hex.EncodeToString(sum[:4])
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+// callerKey is the context key for the name of the authenticated APIKey.
+type callerKey struct{}
+
+// callerFrom returns the name of the APIKey that authenticated the request
+// of ctx, if any.
+func callerFrom(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(callerKey{}).(string)
+	return name, ok
+}
+
+// requireAPIKey returns a handler that serves requests bearing one of keys
+// with next, and rejects all others with 401 Unauthorized. The key's name is
+// added to the request context, for attribution in logs.
+func requireAPIKey(keys []APIKey, logger *slog.Logger, next http.Handler) http.Handler {
+	// Compare fixed-size hashes, so comparison time does not reveal key lengths
+	hashes := make([][sha256.Size]byte, len(keys))
+	for i, key := range keys {
+		hashes[i] = sha256.Sum256([]byte(key.Key))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		match := -1
+		if token := requestToken(r); token != "" {
+			tokenHash := sha256.Sum256([]byte(token))
+			// Check every key, so timing does not reveal which one matched
+			for i := range hashes {
+				if subtle.ConstantTimeCompare(tokenHash[:], hashes[i][:]) == 1 {
+					match = i
+				}
+			}
+		}
+		if match < 0 {
+			logger.Warn("unauthorized request", "remoteAddr", r.RemoteAddr, "method", r.Method, "path", r.URL.Path)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="dank-mcp"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		ctx := context.WithValue(r.Context(), callerKey{}, keys[match].Name)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestToken returns the bearer token or X-API-Key header of r, or "".
+func requestToken(r *http.Request) string {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
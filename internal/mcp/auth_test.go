@@ -0,0 +1,259 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys("# team keys\nalice:s3cret\n\n bob : hunter2 , bare-key\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []APIKey{
+		{Name: "alice", Key: "s3cret"},
+		{Name: "bob", Key: "hunter2"},
+		{Name: apiKeyFingerprint("bare-key"), Key: "bare-key"},
+	}
+	if len(keys) != len(want) {
+		t.Fatalf("got %d keys; want %d", len(keys), len(want))
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("key %d = %+v; want %+v", i, keys[i], want[i])
+		}
+	}
+	if !strings.HasPrefix(keys[2].Name, "key-") || strings.Contains(keys[2].Name, "bare") {
+		t.Errorf("bare key name %q reveals the key", keys[2].Name)
+	}
+
+	for _, bad := range []string{
+		"alice:",
+		"alice:a\nalice:b",
+		"bad name:key",
+		":key",
+		"",
+		"\n  \n",
+		"# no keys yet\n#alice:s3cret\n",
+	} {
+		if _, err := ParseAPIKeys(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestLoadAPIKeys_Empty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.txt")
+	if err := os.WriteFile(path, []byte("# keys go here\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadAPIKeys(path); err == nil {
+		t.Error("expected error for a file with no keys")
+	}
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	keys := []APIKey{{Name: "alice", Key: "s3cret"}, {Name: "bob", Key: "hunter2"}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := requireAPIKey(keys, logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, _ := callerFrom(r.Context())
+		io.WriteString(w, caller)
+	}))
+
+	cases := []struct {
+		header, value string
+		status        int
+		caller        string
+	}{
+		{"", "", http.StatusUnauthorized, ""},
+		{"Authorization", "Bearer wrong", http.StatusUnauthorized, ""},
+		{"Authorization", "Basic s3cret", http.StatusUnauthorized, ""},
+		{"Authorization", "Bearer s3cret", http.StatusOK, "alice"},
+		{"Authorization", "bearer hunter2", http.StatusOK, "bob"},
+		{"X-API-Key", "hunter2", http.StatusOK, "bob"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, HTTPEndpointPath, nil)
+		if c.header != "" {
+			req.Header.Set(c.header, c.value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != c.status {
+			t.Errorf("%s %q: status %d; want %d", c.header, c.value, rec.Code, c.status)
+		}
+		if c.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s %q: missing WWW-Authenticate", c.header, c.value)
+		}
+		if c.status == http.StatusOK && rec.Body.String() != c.caller {
+			t.Errorf("%s %q: caller %q; want %q", c.header, c.value, rec.Body.String(), c.caller)
+		}
+	}
+}
+
+func TestHTTPTransport_APIKeys(t *testing.T) {
+	url := startHTTPServer(t, Config{
+		Transport:     TransportHTTP,
+		HTTPStateless: true,
+		APIKeys:       []APIKey{{Name: "alice", Key: "s3cret"}},
+	})
+
+	if resp, _ := postMCP(t, url, "", queryRequest); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("no key: status %d", resp.StatusCode)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url+HTTPEndpointPath, strings.NewReader(queryRequest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `n\n3\n`) {
+		t.Errorf("with key: status %d, body %s", resp.StatusCode, body)
+	}
+}
+
+func TestConfig_CheckAuth(t *testing.T) {
+	keys := []APIKey{{Name: "alice", Key: "s3cret"}}
+	cases := []struct {
+		name   string
+		config Config
+		ok     bool
+	}{
+		{"stdio", Config{Transport: TransportStdio, HostPort: ":8889"}, true},
+		{"keys on every interface", Config{Transport: TransportHTTP, HostPort: ":8889", APIKeys: keys}, true},
+		{"no keys on every interface", Config{Transport: TransportHTTP, HostPort: ":8889"}, false},
+		{"no keys on a public address", Config{Transport: TransportSSE, HostPort: "0.0.0.0:8889"}, false},
+		{"no keys on a host name", Config{Transport: TransportSSE, HostPort: "example.com:8889"}, false},
+		{"no keys on 127.0.0.1", Config{Transport: TransportHTTP, HostPort: "127.0.0.1:8889"}, true},
+		{"no keys on ::1", Config{Transport: TransportSSE, HostPort: "[::1]:8889"}, true},
+		{"no keys on localhost", Config{Transport: TransportHTTP, HostPort: "localhost:8889"}, true},
+		{"no keys, opted out", Config{Transport: TransportHTTP, HostPort: ":8889", InsecureNoAuth: true}, true},
+	}
+	for _, c := range cases {
+		if err := c.config.CheckAuth(); (err == nil) != c.ok {
+			t.Errorf("%s: CheckAuth() = %v; want ok %v", c.name, err, c.ok)
+		}
+	}
+	if _, err := newHTTPHandler(Config{Transport: TransportHTTP, HostPort: ":8889"}, nil, nil); err == nil {
+		t.Error("newHTTPHandler: expected error without keys on every interface")
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use, to collect logs.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSSETransport_APIKeys(t *testing.T) {
+	var logs syncBuffer
+	baseURL := startLoggingHTTPServer(t, Config{
+		Transport: TransportSSE,
+		APIKeys:   []APIKey{{Name: "alice", Key: "s3cret"}},
+	}, slog.New(slog.NewTextHandler(&logs, nil)))
+
+	send := func(method, target, key, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, target, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	status := func(method, target, key, body string) int {
+		t.Helper()
+		resp := send(method, target, key, body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := status(http.MethodGet, baseURL+"/sse", "", ""); code != http.StatusUnauthorized {
+		t.Errorf("/sse without key: status %d", code)
+	}
+
+	// Open an event stream with the key, which names the session's endpoint
+	stream := send(http.MethodGet, baseURL+"/sse", "s3cret", "")
+	defer stream.Body.Close()
+	events := bufio.NewScanner(stream.Body)
+	var endpoint string
+	for endpoint == "" && events.Scan() {
+		if data, ok := strings.CutPrefix(events.Text(), "data: "); ok {
+			endpoint = data
+		}
+	}
+	base, _ := url.Parse(baseURL)
+	ref, err := url.Parse(endpoint)
+	if err != nil || endpoint == "" {
+		t.Fatalf("no endpoint event: %q, %v", endpoint, err)
+	}
+	messageURL := base.ResolveReference(ref).String()
+
+	if code := status(http.MethodPost, messageURL, "", queryRequest); code != http.StatusUnauthorized {
+		t.Errorf("/message without key: status %d", code)
+	}
+	if code := status(http.MethodPost, messageURL, "wrong", queryRequest); code != http.StatusUnauthorized {
+		t.Errorf("/message with wrong key: status %d", code)
+	}
+	if code := status(http.MethodPost, messageURL, "s3cret", queryRequest); code >= 300 {
+		t.Fatalf("/message with key: status %d", code)
+	}
+
+	// The result arrives on the stream, and the call is logged as alice's
+	var result string
+	for result == "" && events.Scan() {
+		if data, ok := strings.CutPrefix(events.Text(), "data: "); ok && strings.Contains(data, `"id":2`) {
+			result = data
+		}
+	}
+	if !strings.Contains(result, `n\n3\n`) {
+		t.Errorf("query result: %s", result)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(logs.String(), "apiKey=alice") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.Contains(logs.String(), "msg=\"tool call\" tool=query apiKey=alice") {
+		t.Errorf("tool call not attributed to alice:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/AgentDank/dank-mcp/internal/db"
+	"github.com/AgentDank/dank-mcp/pkg/dank"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+)
+
+// BindingTools returns a ToolMap with a registration function for every
+// ToolQuery in the given bindings, keyed by tool name.
+func BindingTools(bindings []dank.Binding) ToolMap {
+	tools := make(ToolMap)
+	for _, b := range bindings {
+		for _, tq := range b.Tools {
+			tools[tq.Name] = MakeToolQueryRegistrar(tq)
+		}
+	}
+	return tools
+}
+
+// MakeToolQueryRegistrar returns a ToolRegistrationFunc that registers tq as
+// an MCP tool. Tool arguments are bound to the query as DuckDB named
+// parameters (e.g. $brand), never interpolated into the SQL text.
+func MakeToolQueryRegistrar(tq dank.ToolQuery) ToolRegistrationFunc {
+	return func(mcpServer *mcp_server.MCPServer, conn *sql.DB) error {
+		if conn == nil {
+			return fmt.Errorf("DuckDB connection is nil")
+		}
+		if err := tq.Validate(); err != nil {
+			return err
+		}
+		// Every parameter the SQL references must be a declared property;
+		// otherwise the driver would silently bind it by position.
+		paramNames, err := db.QueryParamNames(context.Background(), conn, tq.Query)
+		if err != nil {
+			return fmt.Errorf("tool %q: %w", tq.Name, err)
+		}
+		for _, name := range paramNames {
+			if _, ok := tq.InputSchema.Properties[name]; !ok {
+				return fmt.Errorf("tool %q: query parameter $%s is not declared in schema", tq.Name, name)
+			}
+		}
+		schema := tq.InputSchema
+		if schema.Type == "" {
+			schema.Type = "object"
+		}
+		if schema.Properties == nil {
+			schema.Properties = map[string]interface{}{}
+		}
+		schemaJSON, err := json.Marshal(schema)
+		if err != nil {
+			return fmt.Errorf("failed to marshal schema for tool %q: %w", tq.Name, err)
+		}
+		mcpServer.AddTool(mcp.NewToolWithRawSchema(tq.Name, tq.Desc, schemaJSON), makeToolQueryHandler(conn, tq))
+		return nil
+	}
+}
+
+func makeToolQueryHandler(conn *sql.DB, tq dank.ToolQuery) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		conn := requestConn(ctx, conn)
+		args, err := bindToolArguments(tq.InputSchema, request.GetArguments())
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := withQueryTimeout(ctx, tq.QueryTimeout())
+		defer cancel()
+		return runQueryTool(ctx, conn, db.Formats["csv"], resultLimits(ctx, db.Limits{MaxRows: tq.MaxRows, MaxBytes: tq.MaxBytes}), tq.Query, args...)
+	}
+}
+
+// bindToolArguments converts request arguments into sql.Named parameters,
+// one per declared property. Missing optional properties are bound as NULL
+// so queries can test them with "$name IS NULL".
+func bindToolArguments(schema dank.ToolInputSchema, arguments map[string]any) ([]any, error) {
+	for name := range arguments {
+		if _, ok := schema.Properties[name]; !ok {
+			return nil, fmt.Errorf("unknown argument %q", name)
+		}
+	}
+	for _, name := range schema.Required {
+		if v, ok := arguments[name]; !ok || v == nil {
+			return nil, fmt.Errorf("%s must be set", name)
+		}
+	}
+
+	args := make([]any, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		value, err := coerceArgument(schema.PropertyType(name), arguments[name])
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %w", name, err)
+		}
+		args = append(args, sql.Named(name, value))
+	}
+	return args, nil
+}
+
+// coerceArgument checks that a decoded JSON value matches the declared
+// schema type and converts it to the Go type bound for DuckDB.
+func coerceArgument(propType string, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch propType {
+	case "string":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case "number":
+		if f, ok := value.(float64); ok {
+			return f, nil
+		}
+	case "integer":
+		if f, ok := value.(float64); ok && f == math.Trunc(f) && math.Abs(f) <= 1<<53 {
+			return int64(f), nil
+		}
+	case "boolean":
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %T", propType, value)
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/AgentDank/dank-mcp/pkg/dank"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_, err = conn.Exec(`CREATE TABLE brands AS SELECT * FROM (VALUES
+		('Alpha', 'flower', 22.5),
+		('Beta', 'vape', 81.0),
+		('Gamma', 'flower', 28.1)
+	) AS t(brand_name, category, thc)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+var testToolQuery = dank.ToolQuery{
+	Name: "brands_by_category",
+	Desc: "Brands in a category",
+	InputSchema: dank.ToolInputSchema{
+		Type: "object",
+		Properties: map[string]interface{}{
+			"category": map[string]interface{}{"type": "string"},
+			"min_thc":  map[string]interface{}{"type": "number"},
+		},
+		Required: []string{"category"},
+	},
+	Query: "SELECT brand_name FROM brands WHERE category = $category AND ($min_thc IS NULL OR thc >= $min_thc) ORDER BY brand_name",
+}
+
+func callTool(t *testing.T, conn *sql.DB, tq dank.ToolQuery, args map[string]any) (string, error) {
+	t.Helper()
+	var req mcp.CallToolRequest
+	req.Params.Name = tq.Name
+	req.Params.Arguments = args
+	result, err := makeToolQueryHandler(conn, tq)(context.Background(), req)
+	if err != nil {
+		return "", err
+	}
+	return result.Content[0].(mcp.TextContent).Text, nil
+}
+
+func TestToolQuery_BindsParameters(t *testing.T) {
+	conn := openTestDB(t)
+
+	got, err := callTool(t, conn, testToolQuery, map[string]any{"category": "flower"})
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if got != "brand_name\nAlpha\nGamma\n" {
+		t.Errorf("unexpected result:\n%s", got)
+	}
+
+	got, err = callTool(t, conn, testToolQuery, map[string]any{"category": "flower", "min_thc": 25.0})
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if got != "brand_name\nGamma\n" {
+		t.Errorf("unexpected result:\n%s", got)
+	}
+}
+
+func TestToolQuery_NoInterpolation(t *testing.T) {
+	conn := openTestDB(t)
+	got, err := callTool(t, conn, testToolQuery, map[string]any{"category": "flower' OR '1'='1"})
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if got != "brand_name\n" {
+		t.Errorf("injection should match nothing; got:\n%s", got)
+	}
+}
+
+func TestToolQuery_RejectsBadArguments(t *testing.T) {
+	conn := openTestDB(t)
+	cases := []struct {
+		name string
+		args map[string]any
+	}{
+		{"missing required", map[string]any{}},
+		{"null required", map[string]any{"category": nil}},
+		{"wrong type", map[string]any{"category": 42.0}},
+		{"unknown argument", map[string]any{"category": "flower", "limit": 1.0}},
+	}
+	for _, c := range cases {
+		if _, err := callTool(t, conn, testToolQuery, c.args); err == nil {
+			t.Errorf("%s: expected error", c.name)
+		}
+	}
+}
+
+func TestCoerceArgument_Integer(t *testing.T) {
+	if v, err := coerceArgument("integer", 3.0); err != nil || v != int64(3) {
+		t.Errorf("coerceArgument(integer, 3.0) = %v, %v", v, err)
+	}
+	if _, err := coerceArgument("integer", 3.5); err == nil {
+		t.Error("expected error for fractional integer")
+	}
+}
+
+func TestMakeToolQueryRegistrar_RejectsUndeclaredParameter(t *testing.T) {
+	conn := openTestDB(t)
+	tq := testToolQuery
+	tq.Query = "SELECT brand_name FROM brands WHERE category = $category AND thc >= $undeclared"
+
+	srv := mcp_server.NewMCPServer("test", "0")
+	err := MakeToolQueryRegistrar(tq)(srv, conn)
+	if err == nil {
+		t.Fatal("expected error for undeclared parameter")
+	}
+	if !strings.Contains(err.Error(), "undeclared") {
+		t.Errorf("error should name the parameter: %v", err)
+	}
+	if srv.GetTool(tq.Name) != nil {
+		t.Error("tool should not be registered")
+	}
+}
+
+func TestMakeToolQueryRegistrar_Registers(t *testing.T) {
+	conn := openTestDB(t)
+	srv := mcp_server.NewMCPServer("test", "0")
+	if err := MakeToolQueryRegistrar(testToolQuery)(srv, conn); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if srv.GetTool(testToolQuery.Name) == nil {
+		t.Fatal("tool not registered")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/AgentDank/dank-mcp/internal/fetch"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+)
+
+// DatasetsResourceURI is the URI of the resource listing the contents and
+// provenance of the served datasets.
+const DatasetsResourceURI = "dank://datasets"
+
+// ServedDataset is a downloaded dataset being served.
+type ServedDataset struct {
+	ID       string // Dataset id, e.g. "us/ct"
+	Path     string // Path of its installed DuckDB
+	Database string // Name of the database it is served as
+}
+
+// ServedManifest is a served dataset's entry in the datasets resource.
+type ServedManifest struct {
+	Database string `json:"database"`
+	fetch.Manifest
+}
+
+// ReadManifests reads the manifest of each of datasets. It is called when
+// a connection serving them is opened, so that the manifests describe the
+// snapshots it serves, and they are held with it in Config.Swappable.
+func ReadManifests(datasets []ServedDataset) ([]ServedManifest, error) {
+	manifests := make([]ServedManifest, 0, len(datasets))
+	for _, dataset := range datasets {
+		manifest, err := fetch.ReadManifest(dataset.Path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("dataset %s: %w", dataset.ID, err)
+		}
+		manifest.ID = dataset.ID
+		manifests = append(manifests, ServedManifest{Database: dataset.Database, Manifest: manifest})
+	}
+	return manifests, nil
+}
+
+// DatasetsResource returns a ResourceRegistrationFunc that registers the
+// DatasetsResourceURI resource, listing manifests, as read by
+// ReadManifests: what each dataset contains and its license, as described
+// by its catalog, and the catalog, snapshot and sha256 it was installed
+// from, and when. If the connection serving a read was swapped in with
+// manifests of its own, as a refresh does, those are listed instead.
+func DatasetsResource(manifests []ServedManifest) ResourceRegistrationFunc {
+	return func(mcpServer *mcp_server.MCPServer, conn *sql.DB) error {
+		mcpServer.AddResource(mcp.NewResource(DatasetsResourceURI, "datasets",
+			mcp.WithResourceDescription("Contents and provenance of the served datasets: the title, description, tables, jurisdiction, tags, license and attribution of each, and the catalog, snapshot URL and sha256, catalog updated_at, and download time. Read this to learn what a dataset contains before querying it, and cite it to identify the data an answer came from."),
+			mcp.WithMIMEType("application/json"),
+		), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			served := manifests
+			if current, ok := requestManifests(ctx); ok {
+				served = current
+			}
+			text, err := json.MarshalIndent(map[string]any{"datasets": served}, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			return []mcp.ResourceContents{mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(text),
+			}}, nil
+		})
+		return nil
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AgentDank/dank-mcp/internal/db"
+	"github.com/AgentDank/dank-mcp/internal/fetch"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+)
+
+func TestDatasetsResource(t *testing.T) {
+	dir := t.TempDir()
+	ctPath := filepath.Join(dir, "ct.duckdb")
+	manifest := `{"id": "us/ct", "sha256": "abc123", "updated_at": "2026-04-19T00:00:00Z",
+		"url": "https://example.com/ct.zst", "catalog_url": "https://example.com/catalog.json",
+		"downloaded_at": "2026-04-20T12:00:00Z", "license": "CC0-1.0",
+		"tables": [{"name": "brands", "description": "Registered brands"}]}`
+	if err := os.WriteFile(ctPath+".meta.json", []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	manifests, err := ReadManifests([]ServedDataset{
+		{ID: "us/ct", Path: ctPath, Database: "us_ct"},
+		{ID: "us/ma", Path: filepath.Join(dir, "ma.duckdb"), Database: "us_ma"}, // no manifest
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := mcp_server.NewMCPServer("test", "0")
+	register := DatasetsResource(manifests)
+	if err := register(srv, nil); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	got := readResource(t, srv, DatasetsResourceURI)
+	if got.MIMEType != "application/json" {
+		t.Errorf("MIMEType = %q", got.MIMEType)
+	}
+	var body struct {
+		Datasets []map[string]any `json:"datasets"`
+	}
+	if err := json.Unmarshal([]byte(got.Text), &body); err != nil {
+		t.Fatalf("decode %s: %v", got.Text, err)
+	}
+	if len(body.Datasets) != 2 {
+		t.Fatalf("datasets = %v", body.Datasets)
+	}
+	ct, ma := body.Datasets[0], body.Datasets[1]
+	if ct["id"] != "us/ct" || ct["database"] != "us_ct" || ct["sha256"] != "abc123" ||
+		ct["catalog_url"] != "https://example.com/catalog.json" || ct["downloaded_at"] != "2026-04-20T12:00:00Z" {
+		t.Errorf("us/ct = %v", ct)
+	}
+	if tables, _ := ct["tables"].([]any); ct["license"] != "CC0-1.0" || len(tables) != 1 {
+		t.Errorf("us/ct metadata = %v", ct)
+	}
+	if ma["id"] != "us/ma" || ma["database"] != "us_ma" || ma["sha256"] != "" {
+		t.Errorf("us/ma = %v", ma)
+	}
+}
+
+func TestDatasetsResource_Swappable(t *testing.T) {
+	// servedSHA256 returns the sha256 listed by the datasets resource
+	servedSHA256 := func(srv *mcp_server.MCPServer) any {
+		var body struct {
+			Datasets []map[string]any `json:"datasets"`
+		}
+		if err := json.Unmarshal([]byte(readResource(t, srv, DatasetsResourceURI).Text), &body); err != nil || len(body.Datasets) != 1 {
+			t.Fatalf("decode datasets: %v, %v", body.Datasets, err)
+		}
+		return body.Datasets[0]["sha256"]
+	}
+	manifestOf := func(sha256 string) []ServedManifest {
+		return []ServedManifest{{Database: "us_ct", Manifest: fetch.Manifest{ID: "us/ct", SHA256: sha256}}}
+	}
+
+	first := openTestDB(t)
+	swappable := db.NewSwappable(first, manifestOf("first"))
+	t.Cleanup(func() { swappable.Close() })
+	config := Config{Name: "test", Version: "0", DB: first, Swappable: swappable,
+		Resources: ResourceMap{DatasetsResourceURI: DatasetsResource(manifestOf("first"))}}
+	srv, err := newServer(config, slog.New(slog.NewTextHandler(io.Discard, nil)), ToolMap{"query": RegisterQueryTool})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := servedSHA256(srv); got != "first" {
+		t.Errorf("before swap: sha256 %v", got)
+	}
+	// The manifests swapped in with a connection describe what it serves
+	if err := swappable.Swap(openTestDB(t), manifestOf("second")); err != nil {
+		t.Fatal(err)
+	}
+	if got := servedSHA256(srv); got != "second" {
+		t.Errorf("after swap: sha256 %v", got)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AgentDank/dank-mcp/pkg/dank"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+	"github.com/yosida95/uritemplate/v3"
+)
+
+// PromptRegistrationFunc is a function type for registering prompts with the MCP server.
+// It takes an MCPServer and a database, returning an error if any.
+type PromptRegistrationFunc func(mcpServer *mcp_server.MCPServer, conn *sql.DB) error
+
+// PromptMap is a map of names to PromptRegistrationFunc registration functions.
+type PromptMap map[string]PromptRegistrationFunc
+
+//////////////////////////////////////////////////////////////////////////////
+
+// BindingPrompts returns a PromptMap with a registration function for every
+// PromptTemplate in the given bindings, keyed by prompt name.
+func BindingPrompts(bindings []dank.Binding) PromptMap {
+	prompts := make(PromptMap)
+	for _, b := range bindings {
+		for _, pt := range b.Prompts {
+			prompts[pt.Name] = MakePromptTemplateRegistrar(b, pt)
+		}
+	}
+	return prompts
+}
+
+// MakePromptTemplateRegistrar returns a PromptRegistrationFunc that registers
+// pt as an MCP prompt. Message text is rendered with the prompt's arguments,
+// and resource messages embed the result of reading the referenced
+// ResourceQuery of b, with its URI placeholders filled from the arguments.
+func MakePromptTemplateRegistrar(b dank.Binding, pt dank.PromptTemplate) PromptRegistrationFunc {
+	return func(mcpServer *mcp_server.MCPServer, conn *sql.DB) error {
+		if err := pt.Validate(); err != nil {
+			return err
+		}
+
+		// Prepare each message up-front, so errors surface at startup
+		renderers := make([]promptMessageRenderer, len(pt.Messages))
+		for i, msg := range pt.Messages {
+			var err error
+			if msg.Resource != "" {
+				renderers[i], err = makeResourceMessageRenderer(conn, b, msg)
+			} else {
+				renderers[i], err = makeTextMessageRenderer(pt, i)
+			}
+			if err != nil {
+				return fmt.Errorf("prompt %q message %d: %w", pt.Name, i, err)
+			}
+		}
+
+		opts := []mcp.PromptOption{mcp.WithPromptDescription(pt.Desc)}
+		for _, arg := range pt.Arguments {
+			argOpts := []mcp.ArgumentOption{mcp.ArgumentDescription(arg.Desc)}
+			if arg.Required {
+				argOpts = append(argOpts, mcp.RequiredArgument())
+			}
+			opts = append(opts, mcp.WithArgument(arg.Name, argOpts...))
+		}
+		mcpServer.AddPrompt(mcp.NewPrompt(pt.Name, opts...), makePromptTemplateHandler(pt, renderers))
+		return nil
+	}
+}
+
+// promptMessageRenderer renders one prompt message from the typed arguments
+// and their original string values.
+type promptMessageRenderer func(ctx context.Context, args map[string]any, rawArgs map[string]string) (mcp.Content, error)
+
+func makePromptTemplateHandler(pt dank.PromptTemplate, renderers []promptMessageRenderer) mcp_server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args, err := parsePromptArguments(pt, request.Params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+
+		messages := make([]mcp.PromptMessage, len(pt.Messages))
+		for i, msg := range pt.Messages {
+			content, err := renderers[i](ctx, args, request.Params.Arguments)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render message %d: %w", i, err)
+			}
+			messages[i] = mcp.NewPromptMessage(mcp.Role(msg.Role), content)
+		}
+		return mcp.NewGetPromptResult(pt.Desc, messages), nil
+	}
+}
+
+// parsePromptArguments checks the request's string arguments against pt's
+// declared arguments and converts them to their declared types. Every
+// declared argument is present in the result; omitted ones are "".
+func parsePromptArguments(pt dank.PromptTemplate, arguments map[string]string) (map[string]any, error) {
+	for name := range arguments {
+		if _, ok := pt.LookupArgument(name); !ok {
+			return nil, fmt.Errorf("unknown argument %q", name)
+		}
+	}
+
+	args := make(map[string]any, len(pt.Arguments))
+	for _, arg := range pt.Arguments {
+		raw, ok := arguments[arg.Name]
+		if !ok || raw == "" {
+			if arg.Required {
+				return nil, fmt.Errorf("%s must be set", arg.Name)
+			}
+			args[arg.Name] = ""
+			continue
+		}
+		var value any
+		var err error
+		switch arg.ArgType() {
+		case "number":
+			value, err = strconv.ParseFloat(raw, 64)
+		case "integer":
+			value, err = strconv.ParseInt(raw, 10, 64)
+		case "boolean":
+			value, err = strconv.ParseBool(raw)
+		default:
+			value = raw
+		}
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: expected %s, got %q", arg.Name, arg.ArgType(), raw)
+		}
+		args[arg.Name] = value
+	}
+	return args, nil
+}
+
+func makeTextMessageRenderer(pt dank.PromptTemplate, i int) (promptMessageRenderer, error) {
+	tmpl, err := pt.ParseMessage(i)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, args map[string]any, rawArgs map[string]string) (mcp.Content, error) {
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, args); err != nil {
+			return nil, err
+		}
+		return mcp.NewTextContent(sb.String()), nil
+	}, nil
+}
+
+func makeResourceMessageRenderer(conn *sql.DB, b dank.Binding, msg dank.PromptMessage) (promptMessageRenderer, error) {
+	rq, ok := b.LookupResource(msg.Resource)
+	if !ok {
+		return nil, fmt.Errorf("resource %q is not declared in binding %q", msg.Resource, b.Name)
+	}
+	templateParams, handler, err := prepareResourceQuery(conn, rq)
+	if err != nil {
+		return nil, err
+	}
+	var uriTemplate *uritemplate.Template
+	if len(templateParams) > 0 {
+		if uriTemplate, err = uritemplate.New(rq.Uri); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(ctx context.Context, args map[string]any, rawArgs map[string]string) (mcp.Content, error) {
+		var request mcp.ReadResourceRequest
+		request.Params.URI = rq.Uri
+		if uriTemplate != nil {
+			values := uritemplate.Values{}
+			request.Params.Arguments = make(map[string]any, len(templateParams))
+			for _, name := range templateParams {
+				values.Set(name, uritemplate.String(rawArgs[name]))
+				request.Params.Arguments[name] = rawArgs[name]
+			}
+			uri, err := uriTemplate.Expand(values)
+			if err != nil {
+				return nil, fmt.Errorf("failed to expand resource URI: %w", err)
+			}
+			request.Params.URI = uri
+		}
+		contents, err := handler(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		if len(contents) != 1 {
+			return nil, fmt.Errorf("resource %q returned %d contents", rq.Uri, len(contents))
+		}
+		return mcp.NewEmbeddedResource(contents[0]), nil
+	}, nil
+}
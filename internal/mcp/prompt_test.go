@@ -0,0 +1,114 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/AgentDank/dank-mcp/pkg/dank"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+)
+
+var testPromptBinding = dank.Binding{
+	Name: "test",
+	Resources: []dank.ResourceQuery{{
+		Name:     "category-potency",
+		Uri:      "dank://test/potency/{category}",
+		MimeType: "text/csv",
+		Query:    "SELECT brand_name, thc FROM brands WHERE category = $category ORDER BY thc DESC",
+	}},
+	Prompts: []dank.PromptTemplate{{
+		Name: "compare_potency",
+		Desc: "Compare brand THC potency within a category",
+		Arguments: []dank.PromptArgument{
+			{Name: "category", Required: true},
+			{Name: "top", Type: "integer"},
+		},
+		Messages: []dank.PromptMessage{
+			{Role: "user", Text: "Compare {{.category}} brands{{if .top}}, top {{.top}}{{end}}."},
+			{Role: "user", Resource: "dank://test/potency/{category}"},
+		},
+	}},
+}
+
+// getPrompt issues a prompts/get request, returning the result or the
+// JSON-RPC error message.
+func getPrompt(t *testing.T, srv *mcp_server.MCPServer, name string, args map[string]string) (mcp.GetPromptResult, string) {
+	t.Helper()
+	params, _ := json.Marshal(mcp.GetPromptParams{Name: name, Arguments: args})
+	req := `{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":` + string(params) + `}`
+	switch resp := srv.HandleMessage(context.Background(), []byte(req)).(type) {
+	case mcp.JSONRPCResponse:
+		return resp.Result.(mcp.GetPromptResult), ""
+	case mcp.JSONRPCError:
+		return mcp.GetPromptResult{}, resp.Error.Message
+	default:
+		t.Fatalf("unexpected response %#v", resp)
+	}
+	return mcp.GetPromptResult{}, ""
+}
+
+func TestPromptTemplate_Get(t *testing.T) {
+	conn := openTestDB(t)
+	srv := mcp_server.NewMCPServer("test", "0")
+	pt := testPromptBinding.Prompts[0]
+	if err := MakePromptTemplateRegistrar(testPromptBinding, pt)(srv, conn); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	result, errMsg := getPrompt(t, srv, pt.Name, map[string]string{"category": "flower", "top": "2"})
+	if errMsg != "" {
+		t.Fatalf("get: %s", errMsg)
+	}
+	if len(result.Messages) != 2 {
+		t.Fatalf("len(Messages) = %d", len(result.Messages))
+	}
+	text := result.Messages[0].Content.(mcp.TextContent).Text
+	if text != "Compare flower brands, top 2." {
+		t.Errorf("text = %q", text)
+	}
+	embedded := result.Messages[1].Content.(mcp.EmbeddedResource).Resource.(mcp.TextResourceContents)
+	if embedded.URI != "dank://test/potency/flower" {
+		t.Errorf("URI = %q", embedded.URI)
+	}
+	if embedded.Text != "brand_name,thc\nGamma,28.1\nAlpha,22.5\n" {
+		t.Errorf("embedded text = %q", embedded.Text)
+	}
+
+	// Optional arguments may be omitted
+	result, errMsg = getPrompt(t, srv, pt.Name, map[string]string{"category": "vape"})
+	if errMsg != "" {
+		t.Fatalf("get: %s", errMsg)
+	}
+	if text := result.Messages[0].Content.(mcp.TextContent).Text; text != "Compare vape brands." {
+		t.Errorf("text = %q", text)
+	}
+}
+
+func TestPromptTemplate_RejectsBadArguments(t *testing.T) {
+	conn := openTestDB(t)
+	srv := mcp_server.NewMCPServer("test", "0")
+	pt := testPromptBinding.Prompts[0]
+	if err := MakePromptTemplateRegistrar(testPromptBinding, pt)(srv, conn); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	cases := []struct {
+		name    string
+		args    map[string]string
+		wantErr string
+	}{
+		{"missing required", map[string]string{"top": "2"}, "category"},
+		{"wrong type", map[string]string{"category": "flower", "top": "two"}, "integer"},
+		{"unknown argument", map[string]string{"category": "flower", "bogus": "x"}, "bogus"},
+	}
+	for _, c := range cases {
+		_, errMsg := getPrompt(t, srv, pt.Name, c.args)
+		if !strings.Contains(errMsg, c.wantErr) {
+			t.Errorf("%s: error %q should mention %q", c.name, errMsg, c.wantErr)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/AgentDank/dank-mcp/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	DefaultMaxRows  = 1000      // default maximum number of rows in a tool result
+	DefaultMaxBytes = 256 << 10 // default maximum size of a tool result, in bytes
+)
+
+// queryDefaults are the server-wide query settings that handlers apply
+// unless a tool overrides them.
+type queryDefaults struct {
+	timeout time.Duration   // query timeout; 0 means no limit
+	limits  db.Limits       // result size limits
+	values  db.ValueOptions // how result values are rendered
+}
+
+// queryDefaultsKey is the context key for the server's queryDefaults.
+type queryDefaultsKey struct{}
+
+// queryDefaultsFrom returns the queryDefaults carried by ctx, or the zero
+// value (no limits) if there are none.
+func queryDefaultsFrom(ctx context.Context) queryDefaults {
+	defaults, _ := ctx.Value(queryDefaultsKey{}).(queryDefaults)
+	return defaults
+}
+
+// queryDefaultsOptions returns server options that carry the config's query
+// defaults into the context of every tool, resource and prompt handler.
+func queryDefaultsOptions(config Config) []mcp_server.ServerOption {
+	defaults := queryDefaults{timeout: config.QueryTimeout, limits: config.ResultLimits, values: config.Values}
+	return []mcp_server.ServerOption{
+		mcp_server.WithToolHandlerMiddleware(func(next mcp_server.ToolHandlerFunc) mcp_server.ToolHandlerFunc {
+			return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return next(context.WithValue(ctx, queryDefaultsKey{}, defaults), request)
+			}
+		}),
+		mcp_server.WithResourceHandlerMiddleware(func(next mcp_server.ResourceHandlerFunc) mcp_server.ResourceHandlerFunc {
+			return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+				return next(context.WithValue(ctx, queryDefaultsKey{}, defaults), request)
+			}
+		}),
+		mcp_server.WithPromptHandlerMiddleware(func(next mcp_server.PromptHandlerFunc) mcp_server.PromptHandlerFunc {
+			return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+				return next(context.WithValue(ctx, queryDefaultsKey{}, defaults), request)
+			}
+		}),
+	}
+}
+
+// resultLimits returns the server's default result limits from ctx, with
+// any non-zero fields of override taking precedence.
+func resultLimits(ctx context.Context, override db.Limits) db.Limits {
+	limits := queryDefaultsFrom(ctx).limits
+	if override.MaxRows != 0 {
+		limits.MaxRows = override.MaxRows
+	}
+	if override.MaxBytes != 0 {
+		limits.MaxBytes = override.MaxBytes
+	}
+	return limits
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+// runQueryTool runs query with args and returns its rows as a tool result
+// encoded in format, bounded by limits. ctx should already be bounded by
+// withQueryTimeout. If the result is truncated, a note saying so follows
+// the rows, and the structured content reports the rows returned.
+func runQueryTool(ctx context.Context, conn *sql.DB, format db.Format, limits db.Limits, query string, args ...any) (*mcp.CallToolResult, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return queryErrorResult(ctx, fmt.Errorf("query failed: %w", err))
+	}
+	defer rows.Close()
+
+	text, info, err := db.EncodeRows(rows, format, limits, queryDefaultsFrom(ctx).values)
+	if err != nil {
+		return queryErrorResult(ctx, fmt.Errorf("failed to convert rows to %s: %w", format.Name, err))
+	}
+	if !info.Truncated {
+		return mcp.NewToolResultText(text), nil
+	}
+
+	limit := fmt.Sprintf("%d rows", limits.MaxRows)
+	if info.Reason == "max_bytes" {
+		limit = fmt.Sprintf("%d bytes", limits.MaxBytes)
+	}
+	note := fmt.Sprintf("Result truncated at %s: returned the first %d rows, and more rows exist. "+
+		"Add a LIMIT, a narrower WHERE clause, or aggregate with GROUP BY to get a complete result.", limit, info.Rows)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{mcp.NewTextContent(text), mcp.NewTextContent(note)},
+		StructuredContent: map[string]any{
+			"rowsReturned": info.Rows,
+			"truncated":    true,
+			"hasMore":      true,
+			"reason":       info.Reason,
+		},
+	}, nil
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AgentDank/dank-mcp/internal/db"
+	"github.com/AgentDank/dank-mcp/pkg/dank"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+)
+
+func TestQueryTool_Truncates(t *testing.T) {
+	srv := mcp_server.NewMCPServer("test", "0", queryDefaultsOptions(Config{ResultLimits: db.Limits{MaxRows: 2}})...)
+	if err := RegisterQueryTool(srv, openTestDB(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	result := callServerTool(t, srv, "query", map[string]any{"sql": "SELECT brand_name FROM brands ORDER BY brand_name"})
+	if result.IsError || len(result.Content) != 2 {
+		t.Fatalf("unexpected result %#v", result)
+	}
+	if got := result.Content[0].(mcp.TextContent).Text; got != "brand_name\nAlpha\nBeta\n" {
+		t.Errorf("unexpected CSV:\n%s", got)
+	}
+	if note := result.Content[1].(mcp.TextContent).Text; !strings.Contains(note, "more rows exist") || !strings.Contains(note, "LIMIT") {
+		t.Errorf("unexpected note: %s", note)
+	}
+	structured := result.StructuredContent.(map[string]any)
+	if structured["rowsReturned"] != 2 || structured["hasMore"] != true || structured["reason"] != "max_rows" {
+		t.Errorf("unexpected structured content %#v", structured)
+	}
+
+	// A result within the limit is returned as-is
+	result = callServerTool(t, srv, "query", map[string]any{"sql": "SELECT count(*) AS n FROM brands"})
+	if len(result.Content) != 1 || result.StructuredContent != nil {
+		t.Errorf("unexpected result %#v", result)
+	}
+}
+
+func TestToolQuery_LimitsOverride(t *testing.T) {
+	tq := dank.ToolQuery{
+		Name:    "all_brands",
+		Query:   "SELECT brand_name FROM brands ORDER BY brand_name",
+		MaxRows: 1,
+	}
+	srv := mcp_server.NewMCPServer("test", "0", queryDefaultsOptions(Config{ResultLimits: db.Limits{MaxRows: 100}})...)
+	if err := MakeToolQueryRegistrar(tq)(srv, openTestDB(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	result := callServerTool(t, srv, "all_brands", nil)
+	if got := result.Content[0].(mcp.TextContent).Text; got != "brand_name\nAlpha\n" {
+		t.Errorf("unexpected CSV:\n%s", got)
+	}
+}
+
+func TestQueryTool_Format(t *testing.T) {
+	handler := makeQueryHandler(openTestDB(t))
+	const sql = "SELECT brand_name, category FROM brands WHERE thc > 80"
+
+	cases := map[string]string{
+		"":         "brand_name,category\nBeta,vape\n",
+		"csv":      "brand_name,category\nBeta,vape\n",
+		"json":     `[{"brand_name":"Beta","category":"vape"}]`,
+		"ndjson":   "{\"brand_name\":\"Beta\",\"category\":\"vape\"}\n",
+		"markdown": "| brand_name | category |\n| --- | --- |\n| Beta | vape |\n",
+	}
+	for format, want := range cases {
+		args := map[string]any{"sql": sql}
+		if format != "" {
+			args["format"] = format
+		}
+		got, err := callHandler(t, handler, args)
+		if err != nil {
+			t.Errorf("%q: %v", format, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: got %q; want %q", format, got, want)
+		}
+	}
+
+	if _, err := callHandler(t, handler, map[string]any{"sql": sql, "format": "xml"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestQueryTool_RejectsStatements(t *testing.T) {
+	conn := openTestDB(t)
+	handler := makeQueryHandler(conn)
+
+	for _, sql := range []string{
+		"SET threads = 1",
+		"ATTACH ':memory:' AS other",
+		"SELECT 1; DROP TABLE brands",
+	} {
+		var req mcp.CallToolRequest
+		req.Params.Arguments = map[string]any{"sql": sql}
+		result, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%q: %v", sql, err)
+		}
+		structured, _ := result.StructuredContent.(map[string]any)
+		if !result.IsError || structured["error"] != "not_allowed" {
+			t.Errorf("%q: unexpected result %#v", sql, result)
+		}
+	}
+
+	// The multi-statement payload did not run
+	if got, err := callHandler(t, handler, map[string]any{"sql": "SELECT count(*) AS n FROM brands"}); err != nil || got != "n\n3\n" {
+		t.Errorf("brands: %q, %v", got, err)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/AgentDank/dank-mcp/internal/db"
+	"github.com/AgentDank/dank-mcp/pkg/dank"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+)
+
+// ResourceRegistrationFunc is a function type for registering resources with the MCP server.
+// It takes an MCPServer and a database, returning an error if any.
+type ResourceRegistrationFunc func(mcpServer *mcp_server.MCPServer, conn *sql.DB) error
+
+// ResourceMap is a map of URIs (or URI templates) to ResourceRegistrationFunc registration functions.
+type ResourceMap map[string]ResourceRegistrationFunc
+
+//////////////////////////////////////////////////////////////////////////////
+
+// BindingResources returns a ResourceMap with a registration function for
+// every ResourceQuery in the given bindings, keyed by URI.
+func BindingResources(bindings []dank.Binding) ResourceMap {
+	resources := make(ResourceMap)
+	for _, b := range bindings {
+		for _, rq := range b.Resources {
+			resources[rq.Uri] = MakeResourceQueryRegistrar(rq)
+		}
+	}
+	return resources
+}
+
+// MakeResourceQueryRegistrar returns a ResourceRegistrationFunc that registers
+// rq as a static MCP resource, or as a resource template if its URI has
+// {placeholders}. Placeholder values are bound to the query as DuckDB named
+// parameters. Query results are rendered in rq's MIME type.
+func MakeResourceQueryRegistrar(rq dank.ResourceQuery) ResourceRegistrationFunc {
+	return func(mcpServer *mcp_server.MCPServer, conn *sql.DB) error {
+		templateParams, handler, err := prepareResourceQuery(conn, rq)
+		if err != nil {
+			return err
+		}
+		if len(templateParams) > 0 {
+			mcpServer.AddResourceTemplate(mcp.NewResourceTemplate(rq.Uri, rq.Name,
+				mcp.WithTemplateDescription(rq.Desc),
+				mcp.WithTemplateMIMEType(rq.MimeType),
+			), mcp_server.ResourceTemplateHandlerFunc(handler))
+		} else {
+			mcpServer.AddResource(mcp.NewResource(rq.Uri, rq.Name,
+				mcp.WithResourceDescription(rq.Desc),
+				mcp.WithMIMEType(rq.MimeType),
+			), handler)
+		}
+		return nil
+	}
+}
+
+// prepareResourceQuery validates rq against conn and returns its URI template
+// parameters (nil for a static resource) and a handler that reads it.
+func prepareResourceQuery(conn *sql.DB, rq dank.ResourceQuery) ([]string, mcp_server.ResourceHandlerFunc, error) {
+	if err := rq.Validate(); err != nil {
+		return nil, nil, err
+	}
+	templateParams, _ := rq.TemplateParams()
+
+	var format db.Format
+	if rq.Query != "" {
+		if conn == nil {
+			return nil, nil, fmt.Errorf("DuckDB connection is nil")
+		}
+		var ok bool
+		if format, ok = db.FormatForMimeType(rq.MimeType); !ok {
+			return nil, nil, fmt.Errorf("resource %q: unsupported mimeType %q for query", rq.Name, rq.MimeType)
+		}
+		// Every parameter the SQL references must be a URI placeholder
+		paramNames, err := db.QueryParamNames(context.Background(), conn, rq.Query)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resource %q: %w", rq.Name, err)
+		}
+		for _, name := range paramNames {
+			if !containsString(templateParams, name) {
+				return nil, nil, fmt.Errorf("resource %q: query parameter $%s is not a URI placeholder", rq.Name, name)
+			}
+		}
+	}
+
+	return templateParams, makeResourceQueryHandler(conn, rq, templateParams, format), nil
+}
+
+func makeResourceQueryHandler(conn *sql.DB, rq dank.ResourceQuery, templateParams []string, format db.Format) mcp_server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if rq.Query == "" {
+			return []mcp.ResourceContents{mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: rq.MimeType,
+				Text:     rq.RawData,
+			}}, nil
+		}
+
+		args := make([]any, 0, len(templateParams))
+		for _, name := range templateParams {
+			args = append(args, sql.Named(name, templateArgument(request.Params.Arguments[name])))
+		}
+
+		ctx, cancel := withQueryTimeout(ctx, 0)
+		defer cancel()
+		rows, err := requestConn(ctx, conn).QueryContext(ctx, rq.Query, args...)
+		if err != nil {
+			if interrupted := queryInterruption(ctx); interrupted != nil {
+				return nil, interrupted
+			}
+			return nil, fmt.Errorf("query failed: %w", err)
+		}
+		defer rows.Close()
+
+		// Bounded like tool results, so a resource cannot return a whole table
+		text, info, err := db.EncodeRows(rows, format, resultLimits(ctx, db.Limits{}), queryDefaultsFrom(ctx).values)
+		if err != nil {
+			if interrupted := queryInterruption(ctx); interrupted != nil {
+				return nil, interrupted
+			}
+			return nil, fmt.Errorf("failed to render rows as %s: %w", rq.MimeType, err)
+		}
+
+		contents := mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: rq.MimeType,
+			Text:     text,
+		}
+		if info.Truncated {
+			// A note in the text would corrupt it, so truncation is in its metadata
+			contents.Meta = map[string]any{
+				"rowsReturned": info.Rows,
+				"truncated":    true,
+				"hasMore":      true,
+				"reason":       info.Reason,
+			}
+		}
+		return []mcp.ResourceContents{contents}, nil
+	}
+}
+
+// templateArgument converts a matched URI template variable into a value to
+// bind. Unmatched variables are bound as NULL, and list values are joined
+// with commas.
+func templateArgument(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return v
+	case []string:
+		if len(v) == 0 {
+			return nil
+		}
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// containsString returns true if s is in list.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
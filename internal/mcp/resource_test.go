@@ -0,0 +1,144 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/AgentDank/dank-mcp/internal/db"
+	"github.com/AgentDank/dank-mcp/pkg/dank"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+)
+
+func readResource(t *testing.T, srv *mcp_server.MCPServer, uri string) mcp.TextResourceContents {
+	t.Helper()
+	req := `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"` + uri + `"}}`
+	resp := srv.HandleMessage(context.Background(), []byte(req))
+	rpcResp, ok := resp.(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("read %s: unexpected response %#v", uri, resp)
+	}
+	result := rpcResp.Result.(mcp.ReadResourceResult)
+	if len(result.Contents) != 1 {
+		t.Fatalf("read %s: %d contents", uri, len(result.Contents))
+	}
+	return result.Contents[0].(mcp.TextResourceContents)
+}
+
+func TestResourceQuery_Static(t *testing.T) {
+	conn := openTestDB(t)
+	srv := mcp_server.NewMCPServer("test", "0")
+	rq := dank.ResourceQuery{
+		Name:     "flower",
+		Uri:      "dank://test/flower",
+		MimeType: "text/markdown",
+		Query:    "SELECT brand_name, thc FROM brands WHERE category = 'flower' ORDER BY brand_name",
+	}
+	if err := MakeResourceQueryRegistrar(rq)(srv, conn); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	got := readResource(t, srv, rq.Uri)
+	want := "| brand_name | thc |\n| --- | --- |\n| Alpha | 22.5 |\n| Gamma | 28.1 |\n"
+	if got.Text != want {
+		t.Errorf("text =\n%s\nwant\n%s", got.Text, want)
+	}
+	if got.MIMEType != "text/markdown" {
+		t.Errorf("MIMEType = %q", got.MIMEType)
+	}
+}
+
+func TestResourceQuery_Template(t *testing.T) {
+	conn := openTestDB(t)
+	srv := mcp_server.NewMCPServer("test", "0")
+	rq := dank.ResourceQuery{
+		Name:     "by-category",
+		Uri:      "dank://test/category/{category}",
+		MimeType: "application/json",
+		Query:    "SELECT brand_name FROM brands WHERE category = $category ORDER BY brand_name",
+	}
+	if err := MakeResourceQueryRegistrar(rq)(srv, conn); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	got := readResource(t, srv, "dank://test/category/vape")
+	if got.Text != `[{"brand_name":"Beta"}]` {
+		t.Errorf("text = %s", got.Text)
+	}
+	if got.URI != "dank://test/category/vape" {
+		t.Errorf("URI = %q", got.URI)
+	}
+}
+
+func TestResourceQuery_Limits(t *testing.T) {
+	conn := openTestDB(t)
+	srv := mcp_server.NewMCPServer("test", "0", queryDefaultsOptions(Config{ResultLimits: db.Limits{MaxRows: 2}})...)
+	rq := dank.ResourceQuery{
+		Name:     "brands",
+		Uri:      "dank://test/brands",
+		MimeType: "text/csv",
+		Query:    "SELECT brand_name FROM brands ORDER BY brand_name",
+	}
+	if err := MakeResourceQueryRegistrar(rq)(srv, conn); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	got := readResource(t, srv, rq.Uri)
+	if got.Text != "brand_name\nAlpha\nBeta\n" {
+		t.Errorf("text = %q", got.Text)
+	}
+	if got.Meta["truncated"] != true || got.Meta["reason"] != "max_rows" || got.Meta["rowsReturned"] != 2 {
+		t.Errorf("_meta = %v", got.Meta)
+	}
+}
+
+func TestResourceQuery_RawData(t *testing.T) {
+	srv := mcp_server.NewMCPServer("test", "0")
+	rq := dank.ResourceQuery{Name: "readme", Uri: "dank://test/readme", MimeType: "text/plain", RawData: "hello"}
+	if err := MakeResourceQueryRegistrar(rq)(srv, nil); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if got := readResource(t, srv, rq.Uri); got.Text != "hello" {
+		t.Errorf("text = %q", got.Text)
+	}
+}
+
+func TestResourceQuery_RegistrationErrors(t *testing.T) {
+	conn := openTestDB(t)
+	cases := []struct {
+		name    string
+		rq      dank.ResourceQuery
+		wantErr string
+	}{
+		{"unsupported mime", dank.ResourceQuery{Name: "x", Uri: "dank://x", MimeType: "image/png", Query: "SELECT 1"}, "mimeType"},
+		{"param not placeholder", dank.ResourceQuery{Name: "x", Uri: "dank://x/{a}", MimeType: "text/csv", Query: "SELECT $a, $b"}, "$b"},
+		{"static with param", dank.ResourceQuery{Name: "x", Uri: "dank://x", MimeType: "text/csv", Query: "SELECT $a"}, "$a"},
+	}
+	for _, c := range cases {
+		srv := mcp_server.NewMCPServer("test", "0")
+		err := MakeResourceQueryRegistrar(c.rq)(srv, conn)
+		if err == nil {
+			t.Errorf("%s: expected error", c.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("%s: error %q should mention %q", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestQueryMimeTypes(t *testing.T) {
+	// Bindings are validated against dank.QueryMimeTypes, so it must list
+	// exactly the MIME types results can be encoded as
+	for _, mimeType := range dank.QueryMimeTypes {
+		if _, ok := db.FormatForMimeType(mimeType); !ok {
+			t.Errorf("%s is in dank.QueryMimeTypes but has no Format", mimeType)
+		}
+	}
+	for _, format := range db.Formats {
+		if !slices.Contains(dank.QueryMimeTypes, format.MimeType) {
+			t.Errorf("%s is missing from dank.QueryMimeTypes", format.MimeType)
+		}
+	}
+}
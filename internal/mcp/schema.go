@@ -0,0 +1,231 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AgentDank/dank-mcp/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	defaultSampleRows = 10  // default number of rows returned by sample_rows
+	maxSampleRows     = 100 // maximum number of rows returned by sample_rows
+)
+
+// listTablesSQL lists user tables and views. For tables, approx_row_count
+// is DuckDB's estimated_size, an estimate of the number of rows. The
+// $schema filter matches a schema, an attached database such as "us_ct",
+// or both as "us_ct.main".
+const listTablesSQL = `
+SELECT database_name, schema_name, table_name AS name, 'table' AS type, estimated_size AS approx_row_count, comment
+FROM duckdb_tables()
+WHERE NOT internal AND ($schema IS NULL OR $schema IN (schema_name, database_name, database_name || '.' || schema_name))
+UNION ALL
+SELECT database_name, schema_name, view_name AS name, 'view' AS type, NULL AS approx_row_count, comment
+FROM duckdb_views()
+WHERE NOT internal AND ($schema IS NULL OR $schema IN (schema_name, database_name, database_name || '.' || schema_name))
+ORDER BY database_name, schema_name, name`
+
+// resolveTableSQL finds user tables and views by name, optionally within a schema.
+const resolveTableSQL = `
+SELECT database_name, schema_name, table_name FROM duckdb_tables()
+WHERE NOT internal AND table_name = $table AND ($schema IS NULL OR $schema IN (schema_name, database_name, database_name || '.' || schema_name))
+UNION ALL
+SELECT database_name, schema_name, view_name FROM duckdb_views()
+WHERE NOT internal AND view_name = $table AND ($schema IS NULL OR $schema IN (schema_name, database_name, database_name || '.' || schema_name))
+ORDER BY 1, 2`
+
+// describeColumnsSQL describes the columns of a table. The %s is replaced
+// with the optional join against a SUMMARIZE of the table.
+const describeColumnsSQL = `
+SELECT c.column_name, c.data_type, c.is_nullable%s, c.comment
+FROM duckdb_columns() c%s
+WHERE c.database_name = $database AND c.schema_name = $schema AND c.table_name = $table
+ORDER BY c.column_index`
+
+///////////////////////////////////////////////////////////////////////////////
+
+// RegisterListTablesTool registers the "list_tables" tool, which lists the
+// tables and views of the DuckDB database with approximate row counts and
+// comments.
+func RegisterListTablesTool(mcpServer *mcp_server.MCPServer, conn *sql.DB) error {
+	if conn == nil {
+		return fmt.Errorf("DuckDB connection is nil")
+	}
+	mcpServer.AddTool(mcp.NewTool("list_tables",
+		mcp.WithDescription("List the tables and views in the DuckDB database, with their schema, approximate row count and comment, as CSV"),
+		mcp.WithString("schema",
+			mcp.Description("Only list tables in this schema or attached database, e.g. main, us_ct or us_ct.main"),
+		),
+	), makeListTablesHandler(conn))
+	return nil
+}
+
+// RegisterDescribeTableTool registers the "describe_table" tool, which
+// describes the columns of a table or view, with summary statistics.
+func RegisterDescribeTableTool(mcpServer *mcp_server.MCPServer, conn *sql.DB) error {
+	if conn == nil {
+		return fmt.Errorf("DuckDB connection is nil")
+	}
+	mcpServer.AddTool(mcp.NewTool("describe_table",
+		mcp.WithDescription("Describe the columns of a table or view: DuckDB type, nullability, comment and, unless disabled, approximate distinct count, min, max and null percentage. Returns CSV"),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The name of the table or view"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema or attached database of the table, e.g. main, us_ct or us_ct.main, if the name is ambiguous"),
+		),
+		mcp.WithBoolean("summarize",
+			mcp.Description("Include summary statistics, which scans the table (default true)"),
+		),
+	), makeDescribeTableHandler(conn))
+	return nil
+}
+
+// RegisterSampleRowsTool registers the "sample_rows" tool, which returns the
+// first few rows of a table or view.
+func RegisterSampleRowsTool(mcpServer *mcp_server.MCPServer, conn *sql.DB) error {
+	if conn == nil {
+		return fmt.Errorf("DuckDB connection is nil")
+	}
+	mcpServer.AddTool(mcp.NewTool("sample_rows",
+		mcp.WithDescription("Return a small sample of rows from a table or view as CSV"),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The name of the table or view"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema or attached database of the table, e.g. main, us_ct or us_ct.main, if the name is ambiguous"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("The number of rows to return (default %d, max %d)", defaultSampleRows, maxSampleRows)),
+		),
+	), makeSampleRowsHandler(conn))
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+func makeListTablesHandler(conn *sql.DB) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		conn := requestConn(ctx, conn)
+		ctx, cancel := withQueryTimeout(ctx, 0)
+		defer cancel()
+		return runQueryTool(ctx, conn, db.Formats["csv"], resultLimits(ctx, db.Limits{}), listTablesSQL, optionalStringArg(request, "schema"))
+	}
+}
+
+func makeDescribeTableHandler(conn *sql.DB) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		conn := requestConn(ctx, conn)
+		ctx, cancel := withQueryTimeout(ctx, 0)
+		defer cancel()
+		table, err := resolveTable(ctx, conn, request)
+		if err != nil {
+			return queryErrorResult(ctx, err)
+		}
+
+		summaryColumns, summaryJoin := "", ""
+		if request.GetBool("summarize", true) {
+			summaryColumns = ", s.approx_unique AS approx_distinct, s.min, s.max, s.null_percentage"
+			summaryJoin = fmt.Sprintf(" LEFT JOIN (SUMMARIZE %s) s ON s.column_name = c.column_name", table.quoted())
+		}
+		query := fmt.Sprintf(describeColumnsSQL, summaryColumns, summaryJoin)
+
+		return runQueryTool(ctx, conn, db.Formats["csv"], resultLimits(ctx, db.Limits{}), query,
+			sql.Named("database", table.database),
+			sql.Named("schema", table.schema),
+			sql.Named("table", table.name),
+		)
+	}
+}
+
+func makeSampleRowsHandler(conn *sql.DB) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		conn := requestConn(ctx, conn)
+		ctx, cancel := withQueryTimeout(ctx, 0)
+		defer cancel()
+		table, err := resolveTable(ctx, conn, request)
+		if err != nil {
+			return queryErrorResult(ctx, err)
+		}
+		limit := request.GetInt("limit", defaultSampleRows)
+		if limit < 1 || limit > maxSampleRows {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxSampleRows)
+		}
+
+		return runQueryTool(ctx, conn, db.Formats["csv"], resultLimits(ctx, db.Limits{}), fmt.Sprintf("SELECT * FROM %s LIMIT %d", table.quoted(), limit))
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+// tableRef is a fully-qualified reference to a table or view.
+type tableRef struct {
+	database, schema, name string
+}
+
+// quoted returns the reference as quoted SQL, e.g. "memory"."main"."brands".
+func (t tableRef) quoted() string {
+	return db.QuoteIdentifier(t.database) + "." + db.QuoteIdentifier(t.schema) + "." + db.QuoteIdentifier(t.name)
+}
+
+// resolveTable looks up the "table" and optional "schema" arguments of
+// request in the catalog, so only names of existing tables reach the SQL.
+func resolveTable(ctx context.Context, conn *sql.DB, request mcp.CallToolRequest) (tableRef, error) {
+	tableName, err := request.RequireString("table")
+	if err != nil || tableName == "" {
+		return tableRef{}, errors.New("table must be set")
+	}
+
+	rows, err := conn.QueryContext(ctx, resolveTableSQL,
+		sql.Named("table", tableName),
+		optionalStringArg(request, "schema"),
+	)
+	if err != nil {
+		return tableRef{}, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []tableRef
+	for rows.Next() {
+		var t tableRef
+		if err := rows.Scan(&t.database, &t.schema, &t.name); err != nil {
+			return tableRef{}, fmt.Errorf("error scanning row: %w", err)
+		}
+		matches = append(matches, t)
+	}
+	if err := rows.Err(); err != nil {
+		return tableRef{}, fmt.Errorf("error iterating through rows: %w", err)
+	}
+
+	switch len(matches) {
+	case 0:
+		return tableRef{}, fmt.Errorf("table %q not found; use list_tables to see available tables", tableName)
+	case 1:
+		return matches[0], nil
+	default:
+		found := make([]string, len(matches))
+		for i, m := range matches {
+			found[i] = m.database + "." + m.schema + "." + m.name
+		}
+		return tableRef{}, fmt.Errorf("table %q is ambiguous, set schema to the database or schema of one of: %s", tableName, strings.Join(found, ", "))
+	}
+}
+
+// optionalStringArg returns the named string argument of request as a
+// sql.Named parameter, bound as NULL if it is absent or empty.
+func optionalStringArg(request mcp.CallToolRequest, name string) sql.NamedArg {
+	if value := request.GetString(name, ""); value != "" {
+		return sql.Named(name, value)
+	}
+	return sql.Named(name, nil)
+}
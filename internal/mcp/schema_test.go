@@ -0,0 +1,152 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type toolHandler func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
+
+func callHandler(t *testing.T, handler toolHandler, args map[string]any) (string, error) {
+	t.Helper()
+	var req mcp.CallToolRequest
+	req.Params.Arguments = args
+	result, err := handler(context.Background(), req)
+	if err != nil {
+		return "", err
+	}
+	return result.Content[0].(mcp.TextContent).Text, nil
+}
+
+func TestListTables(t *testing.T) {
+	conn := openTestDB(t)
+	if _, err := conn.Exec(`COMMENT ON TABLE brands IS 'Brand registry';
+		CREATE VIEW flower AS SELECT * FROM brands WHERE category = 'flower';
+		CREATE SCHEMA other; CREATE TABLE other.brands (id INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := callHandler(t, makeListTablesHandler(conn), nil)
+	if err != nil {
+		t.Fatalf("list_tables: %v", err)
+	}
+	want := "database_name,schema_name,name,type,approx_row_count,comment\n" +
+		"memory,main,brands,table,3,Brand registry\n" +
+		"memory,main,flower,view,,\n" +
+		"memory,other,brands,table,0,\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got, err = callHandler(t, makeListTablesHandler(conn), map[string]any{"schema": "other"})
+	if err != nil {
+		t.Fatalf("list_tables: %v", err)
+	}
+	if strings.Count(got, "\n") != 2 || !strings.Contains(got, "memory,other,brands") {
+		t.Errorf("schema filter failed:\n%s", got)
+	}
+}
+
+func TestDescribeTable(t *testing.T) {
+	conn := openTestDB(t)
+
+	got, err := callHandler(t, makeDescribeTableHandler(conn), map[string]any{"table": "brands"})
+	if err != nil {
+		t.Fatalf("describe_table: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if lines[0] != "column_name,data_type,is_nullable,approx_distinct,min,max,null_percentage,comment" {
+		t.Errorf("header = %q", lines[0])
+	}
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "brand_name,VARCHAR,true,3,Alpha,Gamma,") {
+		t.Errorf("unexpected description:\n%s", got)
+	}
+
+	got, err = callHandler(t, makeDescribeTableHandler(conn), map[string]any{"table": "brands", "summarize": false})
+	if err != nil {
+		t.Fatalf("describe_table: %v", err)
+	}
+	if !strings.HasPrefix(got, "column_name,data_type,is_nullable,comment\nbrand_name,VARCHAR,true,\n") {
+		t.Errorf("unexpected description:\n%s", got)
+	}
+}
+
+func TestDescribeTable_Errors(t *testing.T) {
+	conn := openTestDB(t)
+	if _, err := conn.Exec(`CREATE SCHEMA other; CREATE TABLE other.brands (id INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name    string
+		args    map[string]any
+		wantErr string
+	}{
+		{"missing table", map[string]any{}, "table must be set"},
+		{"unknown table", map[string]any{"table": "nope"}, "not found"},
+		{"injection", map[string]any{"table": `brands"; DROP TABLE brands; --`}, "not found"},
+		{"ambiguous", map[string]any{"table": "brands"}, "ambiguous"},
+	}
+	for _, c := range cases {
+		_, err := callHandler(t, makeDescribeTableHandler(conn), c.args)
+		if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("%s: error %v should mention %q", c.name, err, c.wantErr)
+		}
+	}
+	if _, err := callHandler(t, makeDescribeTableHandler(conn), map[string]any{"table": "brands", "schema": "other"}); err != nil {
+		t.Errorf("schema should disambiguate: %v", err)
+	}
+}
+
+func TestSampleRows(t *testing.T) {
+	conn := openTestDB(t)
+
+	got, err := callHandler(t, makeSampleRowsHandler(conn), map[string]any{"table": "brands", "limit": 2.0})
+	if err != nil {
+		t.Fatalf("sample_rows: %v", err)
+	}
+	if strings.Count(got, "\n") != 3 || !strings.HasPrefix(got, "brand_name,category,thc\n") {
+		t.Errorf("unexpected sample:\n%s", got)
+	}
+
+	if _, err := callHandler(t, makeSampleRowsHandler(conn), map[string]any{"table": "brands", "limit": 1000.0}); err == nil {
+		t.Error("expected error for limit over max")
+	}
+}
+
+func TestSchemaTools_AttachedDatabases(t *testing.T) {
+	conn := openTestDB(t)
+	conn.SetMaxOpenConns(1) // keep the in-memory attachment on the one connection
+	if _, err := conn.Exec(`ATTACH ':memory:' AS us_ma;
+		CREATE TABLE us_ma.brands (brand_name VARCHAR);
+		INSERT INTO us_ma.brands VALUES ('Delta')`); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := callHandler(t, makeListTablesHandler(conn), map[string]any{"schema": "us_ma"})
+	if err != nil {
+		t.Fatalf("list_tables: %v", err)
+	}
+	if want := "database_name,schema_name,name,type,approx_row_count,comment\nus_ma,main,brands,table,1,\n"; got != want {
+		t.Errorf("list_tables:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := callHandler(t, makeSampleRowsHandler(conn), map[string]any{"table": "brands"}); err == nil || !strings.Contains(err.Error(), "us_ma.main.brands") {
+		t.Errorf("expected ambiguity naming us_ma.main.brands, got %v", err)
+	}
+	for _, schema := range []string{"us_ma", "us_ma.main"} {
+		got, err := callHandler(t, makeSampleRowsHandler(conn), map[string]any{"table": "brands", "schema": schema})
+		if err != nil || got != "brand_name\nDelta\n" {
+			t.Errorf("sample_rows in %s: %q, %v", schema, got, err)
+		}
+	}
+
+	got, err = callHandler(t, makeQueryHandler(conn), map[string]any{"sql": "SELECT brand_name FROM brands UNION ALL SELECT brand_name FROM us_ma.brands ORDER BY 1"})
+	if err != nil || got != "brand_name\nAlpha\nBeta\nDelta\nGamma\n" {
+		t.Errorf("query across databases: %q, %v", got, err)
+	}
+}
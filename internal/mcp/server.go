@@ -3,22 +3,49 @@
 package mcp
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"time"
 
+	"github.com/AgentDank/dank-mcp/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
 	mcp_server "github.com/mark3labs/mcp-go/server"
 )
 
+// Transport selects how the MCP server communicates with hosts.
+type Transport string
+
+const (
+	TransportStdio Transport = "stdio" // JSON-RPC over standard input and output
+	TransportSSE   Transport = "sse"   // Legacy HTTP with Server-Sent Events
+	TransportHTTP  Transport = "http"  // Streamable HTTP
+)
+
+// HTTPEndpointPath is the path of the Streamable HTTP endpoint.
+const HTTPEndpointPath = "/mcp"
+
 // Config is configuration for our MCP server
 type Config struct {
 	Name    string // Service Name
 	Version string // Service Version
 
-	UseSSE      bool   // Use SSE Transport instead of STDIO
-	SSEHostPort string // HostPort to use for SSE
+	Transport      Transport // Transport to serve; empty means TransportStdio
+	HostPort       string    // host:port to listen on for the SSE and HTTP transports
+	HTTPStateless  bool      // Serve Streamable HTTP without sessions
+	APIKeys        []APIKey  // Keys required by the SSE and HTTP transports; see CheckAuth for serving without them
+	InsecureNoAuth bool      // Serve the SSE and HTTP transports without APIKeys on addresses other hosts can reach
+
+	DB           *sql.DB                         // DuckDB connection (read-only, safe-mode applied)
+	Swappable    *db.Swappable[[]ServedManifest] // If set, requests are served by its current connection rather than DB, and clients are notified when it is swapped
+	QueryTimeout time.Duration                   // Default limit on each query's run time; 0 means no limit
+	ResultLimits db.Limits                       // Default limits on the size of each tool result
+	Values       db.ValueOptions                 // How result values are rendered
 
-	DB *sql.DB // DuckDB connection (read-only, safe-mode applied)
+	Resources ResourceMap // Resources to register, in addition to tools
+	Prompts   PromptMap   // Prompts to register, in addition to tools
 }
 
 // ToolRegistrationFunc is a function type for registering tools with the MCP server.
@@ -32,12 +59,53 @@ type ToolMap map[string]ToolRegistrationFunc
 
 // RunRouter runs the MCP server with the given configuration and logger.
 func RunRouter(config Config, logger *slog.Logger, regs map[string]ToolRegistrationFunc) error {
+	mcpServer, err := newServer(config, logger, regs)
+	if err != nil {
+		return err
+	}
+
+	// Run the appropriate server
+	switch config.Transport {
+	case "", TransportStdio:
+		logger.Info("MCP STDIO server started")
+		if err := mcp_server.ServeStdio(mcpServer); err != nil {
+			return fmt.Errorf("MCP STDIO server error: %w", err)
+		}
+	case TransportSSE, TransportHTTP:
+		handler, err := newHTTPHandler(config, logger, mcpServer)
+		if err != nil {
+			return err
+		}
+		if len(config.APIKeys) == 0 && !isLoopbackHostPort(config.HostPort) {
+			logger.Warn("MCP HTTP server has no API keys; anyone who can reach it can query the database")
+		}
+		logger.Info("MCP HTTP server started", "transport", config.Transport, "hostPort", config.HostPort, "apiKeys", len(config.APIKeys))
+		if err := http.ListenAndServe(config.HostPort, handler); err != nil {
+			return fmt.Errorf("MCP %s server error: %w", config.Transport, err)
+		}
+	default:
+		return fmt.Errorf("unknown transport %q", config.Transport)
+	}
+
+	return nil
+}
+
+// newServer creates the MCP server and registers the tools in regs and the
+// resources and prompts in config on it.
+func newServer(config Config, logger *slog.Logger, regs map[string]ToolRegistrationFunc) (*mcp_server.MCPServer, error) {
 	if config.DB == nil {
-		return fmt.Errorf("DuckDB connection is nil")
+		return nil, fmt.Errorf("DuckDB connection is nil")
 	}
 
 	// Create the MCP Server and register Tools on it
-	mcpServer := mcp_server.NewMCPServer(config.Name, config.Version)
+	opts := append(queryDefaultsOptions(config), mcp_server.WithToolHandlerMiddleware(logToolCalls(logger)))
+	if config.Swappable != nil {
+		opts = append(opts, swappableOptions(config.Swappable)...)
+	}
+	mcpServer := mcp_server.NewMCPServer(config.Name, config.Version, opts...)
+	if config.Swappable != nil {
+		notifySwaps(config.Swappable, mcpServer, logger)
+	}
 	toolCount := 0
 	for name, registrator := range regs {
 		if err := registrator(mcpServer, config.DB); err != nil {
@@ -47,22 +115,72 @@ func RunRouter(config Config, logger *slog.Logger, regs map[string]ToolRegistrat
 		}
 	}
 	if toolCount == 0 {
-		return fmt.Errorf("no tools registered")
+		return nil, fmt.Errorf("no tools registered")
 	}
-
-	// Run the appropriate server
-	if config.UseSSE {
-		sseServer := mcp_server.NewSSEServer(mcpServer)
-		logger.Info("MCP SSE server started", "hostPort", config.SSEHostPort)
-		if err := sseServer.Start(config.SSEHostPort); err != nil {
-			return fmt.Errorf("MCP SSE server error: %w", err)
+	for uri, registrator := range config.Resources {
+		if err := registrator(mcpServer, config.DB); err != nil {
+			logger.Error("failed to register resource", "uri", uri, "error", err)
 		}
-	} else {
-		logger.Info("MCP STDIO server started")
-		if err := mcp_server.ServeStdio(mcpServer); err != nil {
-			return fmt.Errorf("MCP STDIO server error: %w", err)
+	}
+	for name, registrator := range config.Prompts {
+		if err := registrator(mcpServer, config.DB); err != nil {
+			logger.Error("failed to register prompt", "name", name, "error", err)
 		}
 	}
 
-	return nil
+	return mcpServer, nil
+}
+
+// newHTTPHandler returns the http.Handler serving mcpServer over the
+// config's network transport. Streamable HTTP is served at HTTPEndpointPath,
+// with sessions unless config.HTTPStateless is set. If config has APIKeys,
+// every request must bear one of them; without them, config must pass
+// CheckAuth.
+func newHTTPHandler(config Config, logger *slog.Logger, mcpServer *mcp_server.MCPServer) (http.Handler, error) {
+	if err := config.CheckAuth(); err != nil {
+		return nil, err
+	}
+	var handler http.Handler
+	switch config.Transport {
+	case TransportSSE:
+		handler = mcp_server.NewSSEServer(mcpServer)
+	case TransportHTTP:
+		sessionOpt := mcp_server.WithStateful(true)
+		if config.HTTPStateless {
+			sessionOpt = mcp_server.WithStateLess(true)
+		}
+		mux := http.NewServeMux()
+		mux.Handle(HTTPEndpointPath, mcp_server.NewStreamableHTTPServer(mcpServer, sessionOpt))
+		handler = mux
+	default:
+		return nil, fmt.Errorf("transport %q is not served over HTTP", config.Transport)
+	}
+	if len(config.APIKeys) > 0 {
+		handler = requireAPIKey(config.APIKeys, logger, handler)
+	}
+	return handler, nil
+}
+
+// logToolCalls returns middleware that logs each tool call with its
+// arguments, attributed to the caller's APIKey if the request had one.
+func logToolCalls(logger *slog.Logger) mcp_server.ToolHandlerMiddleware {
+	return func(next mcp_server.ToolHandlerFunc) mcp_server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			callLogger := logger.With("tool", request.Params.Name)
+			if caller, ok := callerFrom(ctx); ok {
+				callLogger = callLogger.With("apiKey", caller)
+			}
+			start := time.Now()
+			result, err := next(ctx, request)
+			switch {
+			case err != nil:
+				callLogger.Warn("tool call failed", "arguments", request.GetArguments(), "duration", time.Since(start), "error", err)
+			case result != nil && result.IsError:
+				callLogger.Warn("tool call returned an error", "arguments", request.GetArguments(), "duration", time.Since(start))
+			default:
+				callLogger.Info("tool call", "arguments", request.GetArguments(), "duration", time.Since(start))
+			}
+			return result, err
+		}
+	}
 }
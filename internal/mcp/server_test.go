@@ -0,0 +1,105 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// startHTTPServer serves a test MCP server with the query tool over config's
+// transport, returning the base URL.
+func startHTTPServer(t *testing.T, config Config) string {
+	t.Helper()
+	return startLoggingHTTPServer(t, config, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// startLoggingHTTPServer is startHTTPServer, logging to logger.
+func startLoggingHTTPServer(t *testing.T, config Config, logger *slog.Logger) string {
+	t.Helper()
+	config.Name, config.Version = "test", "0"
+	config.DB = openTestDB(t)
+	if config.HostPort == "" {
+		config.HostPort = "127.0.0.1:0" // as httptest listens
+	}
+	mcpServer, err := newServer(config, logger, ToolMap{"query": RegisterQueryTool})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, err := newHTTPHandler(config, logger, mcpServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+// postMCP posts a JSON-RPC message to the Streamable HTTP endpoint.
+func postMCP(t *testing.T, url, sessionID, body string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url+HTTPEndpointPath, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	return resp, string(respBody)
+}
+
+const (
+	initializeRequest = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"0"}}}`
+	queryRequest      = `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"query","arguments":{"sql":"SELECT count(*) AS n FROM brands"}}}`
+)
+
+func TestHTTPTransport_Sessions(t *testing.T) {
+	url := startHTTPServer(t, Config{Transport: TransportHTTP})
+
+	resp, _ := postMCP(t, url, "", initializeRequest)
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if resp.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("initialize: status %d, session %q", resp.StatusCode, sessionID)
+	}
+
+	resp, body := postMCP(t, url, sessionID, queryRequest)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `n\n3\n`) {
+		t.Errorf("query: status %d, body %s", resp.StatusCode, body)
+	}
+
+	// Requests on an unknown session are rejected
+	if resp, _ := postMCP(t, url, "bogus", queryRequest); resp.StatusCode == http.StatusOK {
+		t.Errorf("unknown session: status %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPTransport_Stateless(t *testing.T) {
+	url := startHTTPServer(t, Config{Transport: TransportHTTP, HTTPStateless: true})
+
+	resp, _ := postMCP(t, url, "", initializeRequest)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Mcp-Session-Id") != "" {
+		t.Fatalf("initialize: status %d, session %q", resp.StatusCode, resp.Header.Get("Mcp-Session-Id"))
+	}
+	resp, body := postMCP(t, url, "", queryRequest)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `n\n3\n`) {
+		t.Errorf("query: status %d, body %s", resp.StatusCode, body)
+	}
+}
+
+func TestNewHTTPHandler_RejectsStdio(t *testing.T) {
+	if _, err := newHTTPHandler(Config{Transport: TransportStdio}, nil, nil); err == nil {
+		t.Error("expected error for stdio transport")
+	}
+}
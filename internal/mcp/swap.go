@@ -0,0 +1,89 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/AgentDank/dank-mcp/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+)
+
+// connKey is the context key for the connection serving a request.
+type connKey struct{}
+
+// manifestsKey is the context key for the manifests of the snapshots served
+// by the connection serving a request, as held by the Swappable.
+type manifestsKey struct{}
+
+// requestConn returns the connection serving the request of ctx, which is
+// the current connection of Config.Swappable if it is set, or else conn,
+// the connection the handler was registered with.
+func requestConn(ctx context.Context, conn *sql.DB) *sql.DB {
+	if current, ok := ctx.Value(connKey{}).(*sql.DB); ok {
+		return current
+	}
+	return conn
+}
+
+// requestManifests returns the manifests of the connection serving the
+// request of ctx, and whether it is Config.Swappable's, which holds them.
+func requestManifests(ctx context.Context) ([]ServedManifest, bool) {
+	manifests, ok := ctx.Value(manifestsKey{}).([]ServedManifest)
+	return manifests, ok
+}
+
+// withConn returns ctx with conn, serving manifests, serving its request.
+func withConn(ctx context.Context, conn *sql.DB, manifests []ServedManifest) context.Context {
+	return context.WithValue(context.WithValue(ctx, connKey{}, conn), manifestsKey{}, manifests)
+}
+
+// swappableOptions returns server options that serve each tool call,
+// resource read and prompt with the current connection of swappable,
+// holding it until the request completes so a Swap can drain it, and that
+// notify clients when it is swapped.
+func swappableOptions(swappable *db.Swappable[[]ServedManifest]) []mcp_server.ServerOption {
+	return []mcp_server.ServerOption{
+		mcp_server.WithResourceCapabilities(false, true),
+		mcp_server.WithLogging(),
+		mcp_server.WithToolHandlerMiddleware(func(next mcp_server.ToolHandlerFunc) mcp_server.ToolHandlerFunc {
+			return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				conn, manifests, release := swappable.Acquire()
+				defer release()
+				return next(withConn(ctx, conn, manifests), request)
+			}
+		}),
+		mcp_server.WithResourceHandlerMiddleware(func(next mcp_server.ResourceHandlerFunc) mcp_server.ResourceHandlerFunc {
+			return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+				conn, manifests, release := swappable.Acquire()
+				defer release()
+				return next(withConn(ctx, conn, manifests), request)
+			}
+		}),
+		mcp_server.WithPromptHandlerMiddleware(func(next mcp_server.PromptHandlerFunc) mcp_server.PromptHandlerFunc {
+			return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+				conn, manifests, release := swappable.Acquire()
+				defer release()
+				return next(withConn(ctx, conn, manifests), request)
+			}
+		}),
+	}
+}
+
+// notifySwaps tells the clients of mcpServer when swappable's database is
+// swapped: the resources' contents may have changed, so the resource list
+// is reported changed, and a log message says the data was refreshed.
+func notifySwaps(swappable *db.Swappable[[]ServedManifest], mcpServer *mcp_server.MCPServer, logger *slog.Logger) {
+	swappable.OnSwap(func() {
+		logger.Info("database swapped; notifying clients")
+		mcpServer.SendNotificationToAllClients(mcp.MethodNotificationResourcesListChanged, nil)
+		mcpServer.SendNotificationToAllClients("notifications/message", map[string]any{
+			"level":  mcp.LoggingLevelInfo,
+			"logger": "dank-mcp",
+			"data":   "database refreshed with a newer snapshot",
+		})
+	})
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/AgentDank/dank-mcp/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// testSession is a client session that collects its notifications.
+type testSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) SessionID() string { return "test" }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+
+func TestSwappable_ServesCurrentDatabase(t *testing.T) {
+	first, second := openTestDB(t), openTestDB(t)
+	if _, err := second.Exec("INSERT INTO brands VALUES ('Delta', 'flower', 25.0)"); err != nil {
+		t.Fatal(err)
+	}
+	swappable := db.NewSwappable[[]ServedManifest](first, nil)
+	t.Cleanup(func() { swappable.Close() })
+
+	config := Config{Name: "test", Version: "0", DB: first, Swappable: swappable}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	srv, err := newServer(config, logger, ToolMap{"query": RegisterQueryTool})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := &testSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	if err := srv.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+
+	countBrands := func() string {
+		result := callServerTool(t, srv, "query", map[string]any{"sql": "SELECT count(*) AS n FROM brands"})
+		return result.Content[0].(mcp.TextContent).Text
+	}
+	if got := countBrands(); got != "n\n3\n" {
+		t.Errorf("before swap: %q", got)
+	}
+	if err := swappable.Swap(second, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := countBrands(); got != "n\n4\n" {
+		t.Errorf("after swap: %q", got)
+	}
+
+	for _, want := range []string{mcp.MethodNotificationResourcesListChanged, "notifications/message"} {
+		select {
+		case n := <-session.notifications:
+			if n.Method != want {
+				t.Errorf("notification %q; want %q", n.Method, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s notification", want)
+		}
+	}
+}
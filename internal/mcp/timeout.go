@@ -0,0 +1,67 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// DefaultQueryTimeout is the default limit on how long a single query may run.
+const DefaultQueryTimeout = 60 * time.Second
+
+// queryTimeoutKey is the context key for the query timeout in effect.
+type queryTimeoutKey struct{}
+
+// withQueryTimeout returns a context bounded by timeout, or by the server's
+// default query timeout if timeout is zero. A non-positive timeout means no
+// limit. When the context is done, the DuckDB driver interrupts the query.
+func withQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		timeout = queryDefaultsFrom(ctx).timeout
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	ctx = context.WithValue(ctx, queryTimeoutKey{}, timeout)
+	return context.WithTimeout(ctx, timeout)
+}
+
+// queryInterruption returns an error describing why a query running under
+// ctx was interrupted, or nil if ctx is still live.
+func queryInterruption(ctx context.Context) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		timeout, _ := ctx.Value(queryTimeoutKey{}).(time.Duration)
+		return fmt.Errorf("query timed out after %s", timeout)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return errors.New("query cancelled")
+	default:
+		return nil
+	}
+}
+
+// queryErrorResult converts an error from a query run under ctx into a tool
+// result. A timeout or cancellation is reported as a structured tool error,
+// so the caller can tell it apart from a bad query; other errors are
+// returned as-is.
+func queryErrorResult(ctx context.Context, err error) (*mcp.CallToolResult, error) {
+	interrupted := queryInterruption(ctx)
+	if interrupted == nil {
+		return nil, err
+	}
+	structured := map[string]any{"error": "cancelled", "message": interrupted.Error()}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		structured["error"] = "timeout"
+		if timeout, ok := ctx.Value(queryTimeoutKey{}).(time.Duration); ok {
+			structured["timeout"] = timeout.String()
+		}
+	}
+	result := mcp.NewToolResultStructured(structured, interrupted.Error())
+	result.IsError = true
+	return result, nil
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/AgentDank/dank-mcp/pkg/dank"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcp_server "github.com/mark3labs/mcp-go/server"
+)
+
+// slowSQL is a cross join that runs far longer than any test timeout.
+const slowSQL = "SELECT count(*) FROM range(1000000000) a, range(1000000000) b WHERE a.range + b.range = -1"
+
+// callServerTool issues a tools/call request through srv, so server
+// middleware applies, and returns the tool result.
+func callServerTool(t *testing.T, srv *mcp_server.MCPServer, name string, args map[string]any) mcp.CallToolResult {
+	t.Helper()
+	params, _ := json.Marshal(map[string]any{"name": name, "arguments": args})
+	req := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":` + string(params) + `}`
+	resp, ok := srv.HandleMessage(context.Background(), []byte(req)).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("call %s: unexpected response %#v", name, resp)
+	}
+	return *resp.Result.(*mcp.CallToolResult)
+}
+
+// requireInterrupted checks that result is a structured error with the
+// given status, returned within a few seconds of start.
+func requireInterrupted(t *testing.T, result mcp.CallToolResult, status string, start time.Time) {
+	t.Helper()
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("query was not interrupted promptly: %s", elapsed)
+	}
+	if !result.IsError {
+		t.Fatalf("expected error result, got %#v", result)
+	}
+	structured, ok := result.StructuredContent.(map[string]any)
+	if !ok || structured["error"] != status {
+		t.Errorf("structured content = %#v; want error %q", result.StructuredContent, status)
+	}
+}
+
+func TestQueryTool_Timeout(t *testing.T) {
+	srv := mcp_server.NewMCPServer("test", "0", queryDefaultsOptions(Config{QueryTimeout: 100 * time.Millisecond})...)
+	if err := RegisterQueryTool(srv, openTestDB(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	result := callServerTool(t, srv, "query", map[string]any{"sql": slowSQL})
+	requireInterrupted(t, result, "timeout", start)
+	if got := result.StructuredContent.(map[string]any)["timeout"]; got != "100ms" {
+		t.Errorf("timeout = %v; want 100ms", got)
+	}
+
+	// Fast queries are unaffected
+	result = callServerTool(t, srv, "query", map[string]any{"sql": "SELECT 42 AS answer"})
+	if result.IsError || result.Content[0].(mcp.TextContent).Text != "answer\n42\n" {
+		t.Errorf("unexpected result %#v", result)
+	}
+}
+
+func TestQueryTool_Cancelled(t *testing.T) {
+	handler := makeQueryHandler(openTestDB(t))
+
+	// mcp-go cancels the request context on notifications/cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]any{"sql": slowSQL}
+	start := time.Now()
+	result, err := handler(ctx, req)
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	requireInterrupted(t, *result, "cancelled", start)
+}
+
+func TestToolQuery_TimeoutOverride(t *testing.T) {
+	tq := dank.ToolQuery{
+		Name:    "slow",
+		Query:   slowSQL,
+		Timeout: "100ms",
+	}
+	// The server default is no limit; the tool's own timeout applies
+	srv := mcp_server.NewMCPServer("test", "0", queryDefaultsOptions(Config{})...)
+	if err := MakeToolQueryRegistrar(tq)(srv, openTestDB(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	result := callServerTool(t, srv, "slow", nil)
+	requireInterrupted(t, result, "timeout", start)
+}
+
+func TestResourceQuery_Timeout(t *testing.T) {
+	srv := mcp_server.NewMCPServer("test", "0", queryDefaultsOptions(Config{QueryTimeout: 100 * time.Millisecond})...)
+	rq := dank.ResourceQuery{Name: "slow", Uri: "dank://slow", MimeType: "text/csv", Query: slowSQL}
+	if err := MakeResourceQueryRegistrar(rq)(srv, openTestDB(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	req := `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"dank://slow"}}`
+	resp, ok := srv.HandleMessage(context.Background(), []byte(req)).(mcp.JSONRPCError)
+	if !ok {
+		t.Fatalf("expected error response, got %#v", resp)
+	}
+	if resp.Error.Message != "query timed out after 100ms" {
+		t.Errorf("error = %q", resp.Error.Message)
+	}
+}
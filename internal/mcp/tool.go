@@ -14,39 +14,57 @@ import (
 )
 
 // RegisterQueryTool registers the generic "query" tool, which executes a
-// read-only SQL query against the given DuckDB connection and returns CSV.
+// read-only SQL query against the given DuckDB connection and returns the
+// rows as CSV, or in the format named by the optional "format" argument.
+// Anything but a single read-only statement is rejected without running it.
 func RegisterQueryTool(mcpServer *mcp_server.MCPServer, conn *sql.DB) error {
 	if conn == nil {
 		return fmt.Errorf("DuckDB connection is nil")
 	}
 	mcpServer.AddTool(mcp.NewTool("query",
-		mcp.WithDescription("Execute a read-only SQL query against the DuckDB database and return CSV results"),
+		mcp.WithDescription("Execute a single read-only SQL statement (SELECT, WITH, DESCRIBE, SUMMARIZE or EXPLAIN) against the DuckDB database and return the results, as CSV by default"),
 		mcp.WithString("sql",
 			mcp.Required(),
 			mcp.Description("The SQL query to execute"),
 		),
+		mcp.WithString("format",
+			mcp.Enum(db.FormatNames()...),
+			mcp.Description("The format of the results: csv (default), json (array of objects), ndjson (one object per line) or markdown (table)"),
+		),
 	), makeQueryHandler(conn))
 	return nil
 }
 
 func makeQueryHandler(conn *sql.DB) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		conn := requestConn(ctx, conn)
 		queryStr, err := request.RequireString("sql")
 		if err != nil {
 			return nil, errors.New("sql must be set")
 		}
-
-		rows, err := conn.QueryContext(ctx, queryStr)
-		if err != nil {
-			return nil, fmt.Errorf("query failed: %w", err)
+		formatName := request.GetString("format", "csv")
+		format, ok := db.Formats[formatName]
+		if !ok {
+			return nil, fmt.Errorf("unknown format %q", formatName)
 		}
-		defer rows.Close()
 
-		csvData, err := db.RowsToCSV(rows)
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert rows to CSV: %w", err)
+		ctx, cancel := withQueryTimeout(ctx, 0)
+		defer cancel()
+		if err := db.CheckReadOnlyQuery(ctx, conn, queryStr); err != nil {
+			if errors.Is(err, db.ErrStatementNotAllowed) {
+				return statementNotAllowedResult(err), nil
+			}
+			return queryErrorResult(ctx, err)
 		}
-
-		return mcp.NewToolResultText(csvData), nil
+		return runQueryTool(ctx, conn, format, resultLimits(ctx, db.Limits{}), queryStr)
 	}
 }
+
+// statementNotAllowedResult converts an error from db.CheckReadOnlyQuery into
+// a structured tool error, so the caller can tell a rejected statement apart
+// from a failed one.
+func statementNotAllowedResult(err error) *mcp.CallToolResult {
+	result := mcp.NewToolResultStructured(map[string]any{"error": "not_allowed", "message": err.Error()}, err.Error())
+	result.IsError = true
+	return result
+}
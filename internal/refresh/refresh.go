@@ -0,0 +1,132 @@
+// Copyright (c) 2026 Neomantra Corp
+
+// Package refresh periodically re-runs the download pipeline for served
+// datasets, and hot-swaps the served database when a newer snapshot has
+// been installed.
+package refresh
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/AgentDank/dank-mcp/internal/db"
+	"github.com/AgentDank/dank-mcp/internal/fetch"
+)
+
+// Options configures a Refresher serving connections described by a T.
+type Options[T any] struct {
+	// Paths maps each served dataset id to the path of its installed DuckDB.
+	Paths map[string]string
+
+	// Interval is the time between refreshes. Must be positive.
+	Interval time.Duration
+
+	// Download runs the fetch pipeline for a dataset id, which atomically
+	// installs a newer snapshot at its path if there is one.
+	Download func(ctx context.Context, id string) error
+
+	// Open opens a new read-only, safe-mode connection serving the datasets,
+	// returning it with info describing it to swap in along with it.
+	Open func() (*sql.DB, T, error)
+
+	// Swappable holds the connection being served, swapped on refresh.
+	Swappable *db.Swappable[T]
+
+	// Logger receives progress and warning messages. Must not be nil.
+	Logger *slog.Logger
+}
+
+// DownloadPolicy returns the RefreshPolicy to download with on each refresh,
+// given the policy datasets were downloaded with at startup. RefreshAlways
+// becomes RefreshCatalog, so that a refresh downloads and swaps in a
+// snapshot only if its sha256 has changed, rather than on every tick.
+func DownloadPolicy(startup fetch.RefreshPolicy) fetch.RefreshPolicy {
+	if startup == fetch.RefreshAlways {
+		return fetch.RefreshCatalog
+	}
+	return startup
+}
+
+// Refresher hot-swaps the served database when its datasets are updated.
+type Refresher[T any] struct {
+	opts      Options[T]
+	installed map[string]os.FileInfo // dataset id -> file being served
+}
+
+// New returns a Refresher for the datasets of opts, as currently installed.
+func New[T any](opts Options[T]) (*Refresher[T], error) {
+	if opts.Interval <= 0 {
+		return nil, fmt.Errorf("refresh interval must be positive")
+	}
+	if opts.Download == nil || opts.Open == nil || opts.Swappable == nil || opts.Logger == nil {
+		return nil, fmt.Errorf("refresh: Download, Open, Swappable and Logger are required")
+	}
+	r := &Refresher[T]{opts: opts, installed: make(map[string]os.FileInfo, len(opts.Paths))}
+	for id, path := range opts.Paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat dataset %s: %w", id, err)
+		}
+		r.installed[id] = info
+	}
+	return r, nil
+}
+
+// Run refreshes every Interval until ctx is done. Failures are logged, and
+// the current database is served until a later refresh succeeds.
+func (r *Refresher[T]) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Refresh(ctx); err != nil {
+				r.opts.Logger.Error("refresh failed", "error", err.Error())
+			}
+		}
+	}
+}
+
+// Refresh downloads each dataset and, if any was replaced by a newer
+// snapshot, opens a new connection and swaps it in, draining and closing
+// the old one. Returns whether the database was swapped.
+func (r *Refresher[T]) Refresh(ctx context.Context) (bool, error) {
+	changed := make(map[string]os.FileInfo)
+	for id, path := range r.opts.Paths {
+		if err := r.opts.Download(ctx, id); err != nil {
+			r.opts.Logger.Warn("refresh download failed", "id", id, "error", err.Error())
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			r.opts.Logger.Warn("refresh stat failed", "id", id, "error", err.Error())
+			continue
+		}
+		// Installs replace the file by rename, so a new snapshot is a new file
+		if !os.SameFile(info, r.installed[id]) {
+			changed[id] = info
+		}
+	}
+	if len(changed) == 0 {
+		return false, nil
+	}
+
+	conn, info, err := r.opts.Open()
+	if err != nil {
+		return false, fmt.Errorf("failed to open refreshed database: %w", err)
+	}
+	for id, file := range changed {
+		r.installed[id] = file
+		r.opts.Logger.Info("serving refreshed dataset", "id", id, "path", r.opts.Paths[id])
+	}
+	if err := r.opts.Swappable.Swap(conn, info); err != nil {
+		return false, fmt.Errorf("failed to swap database: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package refresh
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AgentDank/dank-mcp/internal/db"
+	"github.com/AgentDank/dank-mcp/internal/fetch"
+	_ "github.com/duckdb/duckdb-go/v2"
+)
+
+// writeSnapshot atomically installs a DuckDB at path with a single brand.
+func writeSnapshot(t *testing.T, path, brand string) {
+	t.Helper()
+	newPath := path + ".new"
+	conn, err := sql.Open("duckdb", newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec("CREATE TABLE brands (brand_name VARCHAR); INSERT INTO brands VALUES (?)", brand); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if err := os.Rename(newPath, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// servedBrand returns the brand in the database currently served by swappable.
+func servedBrand(t *testing.T, swappable *db.Swappable[struct{}]) string {
+	t.Helper()
+	conn, _, release := swappable.Acquire()
+	defer release()
+	var brand string
+	if err := conn.QueryRow("SELECT brand_name FROM brands").Scan(&brand); err != nil {
+		t.Fatal(err)
+	}
+	return brand
+}
+
+func TestRefresher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dank-data.duckdb")
+	writeSnapshot(t, path, "Alpha")
+
+	open := func() (*sql.DB, struct{}, error) {
+		conn, err := db.OpenReadOnly([]db.Attachment{{Path: path, Alias: "us_ct"}}, "us_ct", db.DefaultHardening)
+		return conn, struct{}{}, err
+	}
+	initial, _, err := open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	swappable := newTestSwappable(t, initial)
+
+	var nextBrand string // installed by the next download, if set
+	refresher, err := New(Options[struct{}]{
+		Paths:    map[string]string{"us/ct": path},
+		Interval: time.Hour,
+		Download: func(ctx context.Context, id string) error {
+			if nextBrand != "" {
+				writeSnapshot(t, path, nextBrand)
+				nextBrand = ""
+			}
+			return nil
+		},
+		Open:      open,
+		Swappable: swappable,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An unchanged snapshot is not reopened
+	if swapped, err := refresher.Refresh(context.Background()); err != nil || swapped {
+		t.Fatalf("unchanged: swapped %v, err %v", swapped, err)
+	}
+
+	// A newly-installed snapshot is swapped in, while a request holds the old one
+	conn, _, release := swappable.Acquire()
+	nextBrand = "Beta"
+	done := make(chan error)
+	go func() {
+		_, err := refresher.Refresh(context.Background())
+		done <- err
+	}()
+	var brand string
+	if err := conn.QueryRow("SELECT brand_name FROM brands").Scan(&brand); err != nil || brand != "Alpha" {
+		t.Errorf("in-flight request: %q, %v", brand, err)
+	}
+	release()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if brand := servedBrand(t, swappable); brand != "Beta" {
+		t.Errorf("served %q after refresh; want Beta", brand)
+	}
+}
+
+func TestDownloadPolicy(t *testing.T) {
+	tests := []struct {
+		startup fetch.RefreshPolicy
+		want    fetch.RefreshPolicy
+	}{
+		{fetch.RefreshTTL, fetch.RefreshTTL},
+		{fetch.RefreshCatalog, fetch.RefreshCatalog},
+		{fetch.RefreshNever, fetch.RefreshNever},
+		// Re-downloading every tick would swap even when nothing changed
+		{fetch.RefreshAlways, fetch.RefreshCatalog},
+	}
+	for _, tt := range tests {
+		if got := DownloadPolicy(tt.startup); got != tt.want {
+			t.Errorf("DownloadPolicy(%q) = %q, want %q", tt.startup, got, tt.want)
+		}
+	}
+}
+
+// newTestSwappable returns a Swappable serving conn, closed at the end of the test.
+func newTestSwappable(t *testing.T, conn *sql.DB) *db.Swappable[struct{}] {
+	swappable := db.NewSwappable(conn, struct{}{})
+	t.Cleanup(func() { swappable.Close() })
+	return swappable
+}
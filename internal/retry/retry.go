@@ -0,0 +1,165 @@
+// Copyright (c) 2026 Neomantra Corp
+
+// Package retry retries idempotent HTTP requests that fail transiently,
+// with exponential backoff and jitter, honoring Retry-After.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Policy configures how requests are retried.
+type Policy struct {
+	// Attempts is the maximum number of attempts, including the first.
+	// Values less than 1 mean a single attempt.
+	Attempts int
+
+	// Backoff is the delay before the first retry, doubled for each
+	// further retry up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// MaxRetryAfter is the longest Retry-After that is waited for. A
+	// response asking for a longer wait is returned rather than retried.
+	// Zero means no limit.
+	MaxRetryAfter time.Duration
+
+	// Jitter is the fraction of each delay, from 0 to 1, that is random,
+	// so that clients failing together do not retry together.
+	Jitter float64
+}
+
+// DefaultPolicy is the policy used when none is configured.
+var DefaultPolicy = Policy{
+	Attempts:      4,
+	Backoff:       time.Second,
+	MaxBackoff:    30 * time.Second,
+	MaxRetryAfter: 5 * time.Minute,
+	Jitter:        0.5,
+}
+
+// sleep waits for d or until ctx is done. Replaced by tests.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Do sends req with client, retrying GET and HEAD requests that fail with
+// a transient error or a 429 or 5xx status. Retry-After is waited for in
+// full, in place of the backoff, unless it exceeds MaxRetryAfter, when the
+// response is returned instead. The response of the last attempt is
+// returned, whatever its status; the bodies of earlier responses are closed.
+func (p Policy) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req.Clone(ctx))
+		last := !idempotent || attempt >= p.Attempts || ctx.Err() != nil
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if last || !IsTransient(err) {
+				return nil, err
+			}
+			delay = p.backoff(attempt)
+		case RetryableStatus(resp.StatusCode):
+			if last {
+				return resp, nil
+			}
+			delay = p.backoff(attempt)
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				if p.MaxRetryAfter > 0 && after > p.MaxRetryAfter {
+					return resp, nil
+				}
+				delay = after
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024)) // so the connection can be reused
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Wait sleeps for the backoff before retrying after the given attempt,
+// for failures Do cannot retry itself, such as reading a response body.
+// Returns early with ctx's error if ctx is done.
+func (p Policy) Wait(ctx context.Context, attempt int) error {
+	return sleep(ctx, p.backoff(attempt))
+}
+
+// backoff returns the delay before retrying after the given attempt.
+func (p Policy) backoff(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 {
+		delay = min(delay, p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
+
+// RetryableStatus returns whether an HTTP status is worth retrying:
+// 429 Too Many Requests, or a 5xx server error other than 501 Not
+// Implemented and 505 HTTP Version Not Supported.
+func RetryableStatus(code int) bool {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return true
+	case code == http.StatusNotImplemented, code == http.StatusHTTPVersionNotSupported:
+		return false
+	default:
+		return code >= 500 && code <= 599
+	}
+}
+
+// IsTransient returns whether err, from sending a request, is worth
+// retrying: a connection refused or reset, a connection closed early,
+// or a network timeout. Cancellation is not transient.
+func IsTransient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryAfter parses a Retry-After header, either delay-seconds or an
+// HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package retry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordSleeps replaces sleep for the test, recording the delays.
+func recordSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var delays []time.Duration
+	orig := sleep
+	sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { sleep = orig })
+	return &delays
+}
+
+// failingServer fails the first failures requests with fail, then succeeds.
+func failingServer(t *testing.T, failures int32, fail http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			fail(w, r)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func status(code int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(code) }
+}
+
+func get(t *testing.T, p Policy, srv *httptest.Server, method string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), method, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := p.Do(srv.Client(), req)
+	if resp != nil {
+		t.Cleanup(func() { resp.Body.Close() })
+	}
+	return resp, err
+}
+
+var testPolicy = Policy{Attempts: 4, Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+func TestDo_Retries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int32
+		fail     http.HandlerFunc
+		method   string
+		wantCode int
+		wantReqs int32
+	}{
+		{"502 then ok", 2, status(http.StatusBadGateway), http.MethodGet, http.StatusOK, 3},
+		{"429 then ok", 1, status(http.StatusTooManyRequests), http.MethodGet, http.StatusOK, 2},
+		{"503 exhausts attempts", 10, status(http.StatusServiceUnavailable), http.MethodGet, http.StatusServiceUnavailable, 4},
+		{"404 not retried", 1, status(http.StatusNotFound), http.MethodGet, http.StatusNotFound, 1},
+		{"501 not retried", 1, status(http.StatusNotImplemented), http.MethodGet, http.StatusNotImplemented, 1},
+		{"POST not retried", 1, status(http.StatusBadGateway), http.MethodPost, http.StatusBadGateway, 1},
+		{"HEAD retried", 1, status(http.StatusBadGateway), http.MethodHead, http.StatusOK, 2},
+		{"connection reset", 2, func(w http.ResponseWriter, r *http.Request) {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+		}, http.MethodGet, http.StatusOK, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delays := recordSleeps(t)
+			srv, requests := failingServer(t, tt.failures, tt.fail)
+			resp, err := get(t, testPolicy, srv, tt.method)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("status %d; want %d", resp.StatusCode, tt.wantCode)
+			}
+			if got := requests.Load(); got != tt.wantReqs {
+				t.Errorf("%d requests; want %d", got, tt.wantReqs)
+			}
+			if len(*delays) != int(tt.wantReqs)-1 {
+				t.Errorf("%d sleeps; want %d", len(*delays), tt.wantReqs-1)
+			}
+		})
+	}
+}
+
+func TestDo_RetryAfter(t *testing.T) {
+	delays := recordSleeps(t)
+	srv, _ := failingServer(t, 2, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120") // waited in full, beyond MaxBackoff
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	p := Policy{Attempts: 3, Backoff: time.Minute, MaxBackoff: 5 * time.Second, MaxRetryAfter: 5 * time.Minute}
+	if _, err := get(t, p, srv, http.MethodGet); err != nil {
+		t.Fatal(err)
+	}
+	if got := *delays; len(got) != 2 || got[0] != 2*time.Minute || got[1] != 2*time.Minute {
+		t.Errorf("delays %v; want [2m0s 2m0s]", got)
+	}
+
+	// Retry-After beyond MaxRetryAfter is not retried early, but returned
+	delays = recordSleeps(t)
+	srv, requests := failingServer(t, 1, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	resp, err := get(t, p, srv, http.MethodGet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests || requests.Load() != 1 || len(*delays) != 0 {
+		t.Errorf("status %d after %d requests, delays %v; want 429 after 1, no delays", resp.StatusCode, requests.Load(), *delays)
+	}
+
+	// Retry-After replaces the backoff, even when shorter
+	delays = recordSleeps(t)
+	srv, _ = failingServer(t, 1, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	if _, err := get(t, p, srv, http.MethodGet); err != nil {
+		t.Fatal(err)
+	}
+	if got := *delays; len(got) != 1 || got[0] != 2*time.Second {
+		t.Errorf("delays %v; want [2s]", got)
+	}
+
+	if d, ok := retryAfter("3"); !ok || d != 3*time.Second {
+		t.Errorf("retryAfter(3) = %v, %v", d, ok)
+	}
+	if d, ok := retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); !ok || d < 59*time.Minute {
+		t.Errorf("retryAfter(date) = %v, %v", d, ok)
+	}
+	if _, ok := retryAfter("soon"); ok {
+		t.Error("retryAfter(soon) should not parse")
+	}
+}
+
+func TestDo_ContextCanceled(t *testing.T) {
+	srv, requests := failingServer(t, 10, status(http.StatusBadGateway))
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	_, err := Policy{Attempts: 10, Backoff: time.Hour}.Do(srv.Client(), req)
+	if err == nil {
+		t.Fatal("expected error when canceled")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("%d requests; want 1", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := Policy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := p.backoff(attempt + 1); got != want {
+			t.Errorf("backoff(%d) = %v; want %v", attempt+1, got, want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for range 100 {
+		if got := p.backoff(2); got < time.Second || got > 2*time.Second {
+			t.Fatalf("jittered backoff %v outside [1s, 2s]", got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2026 Neomantra Corp
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AgentDank/dank-mcp/internal/cache"
+	"github.com/AgentDank/dank-mcp/internal/catalog"
+	"github.com/AgentDank/dank-mcp/internal/retry"
+	"gopkg.in/yaml.v3"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+
+// listFormats are the formats --list can print in, the first the default.
+var listFormats = []string{"table", "json", "yaml"}
+
+// listSorts are the orders --list can sort in, the first the default.
+var listSorts = []string{"id", "updated"}
+
+// listOptions configures --list.
+type listOptions struct {
+	Output string         // One of listFormats
+	Sort   string         // One of listSorts
+	Filter catalog.Filter // Datasets to list
+}
+
+// Statuses of a listed dataset's installed snapshot.
+const (
+	statusNotInstalled = "not installed"
+	statusInstalled    = "installed" // installed without a manifest, so unknown
+	statusCurrent      = "current"
+	statusUpdate       = "update"  // the catalog lists a newer snapshot
+	statusDiffers      = "differs" // the catalog lists another snapshot, not newer
+)
+
+// listedDataset is a dataset as listed by --list: its catalog entry and
+// the state of its installed snapshot.
+type listedDataset struct {
+	ID string `json:"id"`
+	catalog.DatasetEntry
+	Catalog string `json:"catalog"` // Location of the catalog it is listed in
+
+	Installed       bool   `json:"installed"`
+	InstalledSHA256 string `json:"installed_sha256,omitempty"` // Catalog's sha256 of the installed snapshot, as its manifest records, if known
+	SHA256Match     *bool  `json:"sha256_match,omitempty"`     // Whether that is the listed sha256, if known; the file is not re-hashed
+	UpdateAvailable bool   `json:"update_available"`
+	Status          string `json:"status"`
+}
+
+// runList fetches and merges the catalogs at catalogURLs, and prints the
+// datasets in them selected by opts, with the state of their snapshots
+// installed in cacheDir. Returns the exit code.
+func runList(catalogURLs []string, keys catalog.Keyring, policy retry.Policy, cacheDir string, opts listOptions) int {
+	catalogs := make([]catalog.Catalog, 0, len(catalogURLs))
+	for _, catalogURL := range catalogURLs {
+		cat, _, err := catalog.FetchIfModified(context.Background(), catalogURL, nil, policy, keys, catalog.Validators{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to fetch catalog %s: %s\n", catalogURL, err.Error())
+			if errors.Is(err, catalog.ErrUnverified) {
+				fmt.Fprintln(os.Stderr, "trust its signer with --catalog-key, or accept it unsigned with --insecure-catalog")
+			}
+			return 1
+		}
+		catalogs = append(catalogs, cat)
+	}
+	cat, shadowed := catalog.Merge(catalogs...)
+	for _, s := range shadowed {
+		fmt.Fprintf(os.Stderr, "%s in %s is shadowed by %s\n", s.ID, s.Source, s.By)
+	}
+	installed, _, err := cache.Scan(cacheDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return 1
+	}
+
+	datasets := listDatasets(cat, installed, opts)
+	switch opts.Output {
+	case "json":
+		err = writeListJSON(os.Stdout, datasets)
+	case "yaml":
+		err = writeListYAML(os.Stdout, datasets)
+	default:
+		err = writeListTable(os.Stdout, datasets)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// listDatasets returns the datasets in cat selected by opts, sorted as
+// it says, with the state of their snapshots in installed.
+func listDatasets(cat catalog.Catalog, installed []cache.Entry, opts listOptions) []listedDataset {
+	datasets := make([]listedDataset, 0, len(cat.Datasets))
+	for id, entry := range cat.Datasets {
+		if !opts.Filter.Matches(id, entry) {
+			continue
+		}
+		dataset := listedDataset{ID: id, DatasetEntry: entry, Catalog: entry.Source, Status: statusNotInstalled}
+		if i := slices.IndexFunc(installed, func(e cache.Entry) bool { return e.ID == id }); i >= 0 {
+			setInstalled(&dataset, installed[i])
+		}
+		datasets = append(datasets, dataset)
+	}
+	sort.Slice(datasets, func(i, j int) bool {
+		if opts.Sort == "updated" {
+			// Newest first, and those without a valid updated_at last
+			a, aOK := parseUpdatedAt(datasets[i].UpdatedAt)
+			b, bOK := parseUpdatedAt(datasets[j].UpdatedAt)
+			if aOK != bOK {
+				return aOK
+			}
+			if !a.Equal(b) {
+				return a.After(b)
+			}
+		}
+		return datasets[i].ID < datasets[j].ID
+	})
+	return datasets
+}
+
+// parseUpdatedAt parses an updated_at, an RFC 3339 time. Returns false if
+// it is empty or invalid.
+func parseUpdatedAt(s string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+// setInstalled sets the state of dataset's snapshot, installed as e. It
+// is matched by the catalog sha256 its manifest records, without re-hashing
+// the file, which `cache verify` does.
+func setInstalled(dataset *listedDataset, e cache.Entry) {
+	dataset.Installed, dataset.Status = true, statusInstalled
+	if !e.HasManifest || e.Manifest.SHA256 == "" {
+		return
+	}
+	match := e.Manifest.SHA256 == dataset.SHA256
+	dataset.InstalledSHA256, dataset.SHA256Match = e.Manifest.SHA256, &match
+	listed, listedOK := parseUpdatedAt(dataset.UpdatedAt)
+	installed, installedOK := parseUpdatedAt(e.Manifest.UpdatedAt)
+	switch {
+	case match:
+		dataset.Status = statusCurrent
+	case !listedOK || !installedOK || listed.After(installed):
+		dataset.Status, dataset.UpdateAvailable = statusUpdate, true
+	default:
+		dataset.Status = statusDiffers
+	}
+}
+
+// writeListTable writes datasets as a table with aligned columns.
+func writeListTable(w io.Writer, datasets []listedDataset) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTITLE\tUPDATED\tSIZE\tJURISDICTION\tLICENSE\tTAGS\tTABLES\tSTATUS\tSOURCE")
+	for _, d := range datasets {
+		var size string
+		if d.CompressedSize > 0 {
+			size = formatBytes(d.CompressedSize)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", d.ID, d.Title, d.UpdatedAt, size, d.Jurisdiction,
+			d.License, strings.Join(d.Tags, ","), strings.Join(d.TableNames(), ","), d.Status, d.Catalog)
+	}
+	return tw.Flush()
+}
+
+// writeListJSON writes datasets as a JSON document {"datasets": [...]}.
+func writeListJSON(w io.Writer, datasets []listedDataset) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{"datasets": datasets})
+}
+
+// writeListYAML writes datasets as the YAML equivalent of writeListJSON,
+// with the same fields in the same order.
+func writeListYAML(w io.Writer, datasets []listedDataset) error {
+	body, err := json.Marshal(map[string]any{"datasets": datasets})
+	if err != nil {
+		return err
+	}
+	// JSON is YAML, so is decoded as it is, and then re-styled as blocks
+	var doc yaml.Node
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return err
+	}
+	clearStyle(&doc)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// clearStyle clears the style of node and its descendants, so that they
+// are encoded in yaml's default block style.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
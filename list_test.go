@@ -0,0 +1,234 @@
+// Copyright (c) 2026 Neomantra Corp
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/AgentDank/dank-mcp/internal/cache"
+	"github.com/AgentDank/dank-mcp/internal/catalog"
+	"github.com/AgentDank/dank-mcp/internal/fetch"
+	"gopkg.in/yaml.v3"
+)
+
+// installedAs returns a cache entry for id with a manifest recording
+// sha256 and updatedAt.
+func installedAs(id, sha256, updatedAt string) cache.Entry {
+	return cache.Entry{ID: id, HasManifest: true,
+		Manifest: fetch.Manifest{ID: id, SHA256: sha256, UpdatedAt: updatedAt}}
+}
+
+func TestSetInstalled(t *testing.T) {
+	const listedAt = "2026-04-19T00:00:00Z"
+	matched, mismatched := true, false
+	tests := []struct {
+		name       string
+		updatedAt  string // of the listed snapshot
+		entry      cache.Entry
+		wantStatus string
+		wantMatch  *bool
+		wantUpdate bool
+	}{
+		{"no manifest", listedAt, cache.Entry{ID: "us/ct"}, statusInstalled, nil, false},
+		{"no sha256", listedAt, installedAs("us/ct", "", listedAt), statusInstalled, nil, false},
+		{"same sha256", listedAt, installedAs("us/ct", "listed", "2020-01-01T00:00:00Z"), statusCurrent, &matched, false},
+		{"listed newer", listedAt, installedAs("us/ct", "older", "2026-04-01T00:00:00Z"), statusUpdate, &mismatched, true},
+		{"installed newer", listedAt, installedAs("us/ct", "newer", "2026-05-01T00:00:00Z"), statusDiffers, &mismatched, false},
+		{"same updated_at", listedAt, installedAs("us/ct", "other", listedAt), statusDiffers, &mismatched, false},
+		{"listed updated_at invalid", "April", installedAs("us/ct", "older", listedAt), statusUpdate, &mismatched, true},
+		{"installed updated_at missing", listedAt, installedAs("us/ct", "older", ""), statusUpdate, &mismatched, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataset := listedDataset{ID: "us/ct", DatasetEntry: catalog.DatasetEntry{SHA256: "listed", UpdatedAt: tt.updatedAt}}
+			setInstalled(&dataset, tt.entry)
+			if !dataset.Installed || dataset.Status != tt.wantStatus || dataset.UpdateAvailable != tt.wantUpdate {
+				t.Errorf("installed %v, status %q, update %v; want true, %q, %v",
+					dataset.Installed, dataset.Status, dataset.UpdateAvailable, tt.wantStatus, tt.wantUpdate)
+			}
+			if !reflect.DeepEqual(dataset.SHA256Match, tt.wantMatch) {
+				t.Errorf("sha256 match %v; want %v", dataset.SHA256Match, tt.wantMatch)
+			}
+			if tt.wantMatch != nil && dataset.InstalledSHA256 != tt.entry.Manifest.SHA256 {
+				t.Errorf("installed sha256 %q; want %q", dataset.InstalledSHA256, tt.entry.Manifest.SHA256)
+			}
+		})
+	}
+}
+
+// testCatalog lists datasets updated at various times, some not validly.
+var testCatalog = catalog.Catalog{Version: 2, Datasets: map[string]catalog.DatasetEntry{
+	"us/ct": {Title: "Connecticut", SHA256: "ct", UpdatedAt: "2026-04-19T00:00:00Z", Source: "ct-catalog",
+		Metadata: catalog.Metadata{Jurisdiction: "US-CT", CompressedSize: 1536, Tags: []string{"retail", "labs"},
+			Tables: []catalog.Table{{Name: "brands"}, {Name: "tests", Description: "THC potency"}}}},
+	"us/ma": {Title: "Massachusetts", SHA256: "ma", UpdatedAt: "2026-04-20T01:00:00+02:00",
+		Metadata: catalog.Metadata{Jurisdiction: "US-MA"}},
+	"us/ny": {Title: "New York", SHA256: "ny", UpdatedAt: "2026-04-20T00:00:00Z",
+		Metadata: catalog.Metadata{Jurisdiction: "US-NY"}},
+	"ca/on": {Title: "Ontario", SHA256: "on", UpdatedAt: "last week",
+		Metadata: catalog.Metadata{Jurisdiction: "CA-ON"}},
+	"ca/bc": {Title: "British Columbia", SHA256: "bc",
+		Metadata: catalog.Metadata{Jurisdiction: "CA-BC"}},
+}}
+
+func TestListDatasets(t *testing.T) {
+	installed := []cache.Entry{installedAs("us/ct", "ct", "2026-04-19T00:00:00Z"), {ID: "us/ma"}}
+	tests := []struct {
+		name    string
+		opts    listOptions
+		wantIDs []string
+	}{
+		{"by id", listOptions{Sort: "id"}, []string{"ca/bc", "ca/on", "us/ct", "us/ma", "us/ny"}},
+		// us/ma is at 23:00Z the day before us/ny, though it is written later;
+		// those without a valid updated_at are last, by id
+		{"by updated", listOptions{Sort: "updated"}, []string{"us/ny", "us/ma", "us/ct", "ca/bc", "ca/on"}},
+		{"region", listOptions{Sort: "id", Filter: catalog.Filter{Regions: []string{"ca"}}}, []string{"ca/bc", "ca/on"}},
+		{"jurisdiction", listOptions{Sort: "id", Filter: catalog.Filter{Regions: []string{"US-NY", "us/ct"}}}, []string{"us/ct", "us/ny"}},
+		{"search", listOptions{Sort: "id", Filter: catalog.Filter{Search: "potency"}}, []string{"us/ct"}},
+		{"no match", listOptions{Sort: "id", Filter: catalog.Filter{Regions: []string{"mx"}}}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			datasets := listDatasets(testCatalog, installed, tt.opts)
+			ids := make([]string, 0, len(datasets))
+			for _, d := range datasets {
+				ids = append(ids, d.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("ids %q; want %q", ids, tt.wantIDs)
+			}
+		})
+	}
+
+	statuses := map[string]string{}
+	for _, d := range listDatasets(testCatalog, installed, listOptions{Sort: "id"}) {
+		statuses[d.ID] = d.Status
+	}
+	want := map[string]string{"ca/bc": statusNotInstalled, "ca/on": statusNotInstalled,
+		"us/ct": statusCurrent, "us/ma": statusInstalled, "us/ny": statusNotInstalled}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses %v; want %v", statuses, want)
+	}
+}
+
+func TestWriteList(t *testing.T) {
+	installed := []cache.Entry{installedAs("us/ct", "ct", "2026-04-19T00:00:00Z")}
+	datasets := listDatasets(testCatalog, installed, listOptions{Sort: "id", Filter: catalog.Filter{Regions: []string{"us/ct", "us/ny"}}})
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeListTable(&buf, datasets); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("table has %d lines; want 3:\n%s", len(lines), buf.String())
+		}
+		// Each column starts at the same offset on every line
+		for _, column := range []string{"TITLE", "UPDATED", "SIZE", "STATUS"} {
+			if !strings.Contains(lines[0], column) {
+				t.Errorf("header %q missing %s", lines[0], column)
+			}
+		}
+		status := strings.Index(lines[0], "STATUS")
+		if !strings.HasPrefix(lines[1][status:], statusCurrent) || !strings.HasPrefix(lines[2][status:], statusNotInstalled) {
+			t.Errorf("STATUS column misaligned:\n%s", buf.String())
+		}
+		size := strings.Index(lines[0], "SIZE")
+		if !strings.HasPrefix(lines[1][size:], "1.5 KiB") || !strings.HasPrefix(lines[2][size:], " ") {
+			t.Errorf("SIZE column misaligned:\n%s", buf.String())
+		}
+		if fields := strings.Fields(lines[1]); fields[0] != "us/ct" || !slices.Contains(fields, "retail,labs") ||
+			!slices.Contains(fields, "brands,tests") || fields[len(fields)-1] != "ct-catalog" {
+			t.Errorf("us/ct row %q", lines[1])
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeListJSON(&buf, datasets); err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Datasets []map[string]any `json:"datasets"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &body); err != nil || len(body.Datasets) != 2 {
+			t.Fatalf("decode %v: %v", body.Datasets, err)
+		}
+		ct, ny := body.Datasets[0], body.Datasets[1]
+		if ct["id"] != "us/ct" || ct["status"] != statusCurrent || ct["installed"] != true ||
+			ct["sha256_match"] != true || ct["installed_sha256"] != "ct" || ct["catalog"] != "ct-catalog" {
+			t.Errorf("us/ct = %v", ct)
+		}
+		if _, ok := ny["sha256_match"]; ny["id"] != "us/ny" || ny["installed"] != false || ok {
+			t.Errorf("us/ny = %v", ny)
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeListYAML(&buf, datasets); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(buf.String(), "datasets:\n  - id: us/ct\n") || strings.ContainsAny(buf.String(), "{[") {
+			t.Errorf("not in block style:\n%s", buf.String())
+		}
+		// The same document as the JSON, with its fields in the same order;
+		// both are decoded from JSON, so numbers are alike
+		var yamlBody, jsonBody any
+		if err := yaml.Unmarshal(buf.Bytes(), &yamlBody); err != nil {
+			t.Fatal(err)
+		}
+		json.Unmarshal(mustJSON(t, yamlBody), &yamlBody)
+		json.Unmarshal(mustJSON(t, map[string]any{"datasets": datasets}), &jsonBody)
+		if !reflect.DeepEqual(yamlBody, jsonBody) {
+			t.Errorf("yaml %v; want %v", yamlBody, jsonBody)
+		}
+		jsonKeys := keyOrder(t, mustJSON(t, datasets[0]))
+		var doc yaml.Node
+		if err := yaml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		first := doc.Content[0].Content[1].Content[0]
+		var yamlKeys []string
+		for i := 0; i < len(first.Content); i += 2 {
+			yamlKeys = append(yamlKeys, first.Content[i].Value)
+		}
+		if !slices.Equal(yamlKeys, jsonKeys) {
+			t.Errorf("yaml keys %q; want %q", yamlKeys, jsonKeys)
+		}
+	})
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	body, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+// keyOrder returns the keys of the JSON object body, in order.
+func keyOrder(t *testing.T, body []byte) []string {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.Token() // {
+	var keys []string
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keys
+}
@@ -4,18 +4,25 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
-	"sort"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/AgentDank/dank-mcp/data"
 	"github.com/AgentDank/dank-mcp/internal/catalog"
 	"github.com/AgentDank/dank-mcp/internal/db"
 	"github.com/AgentDank/dank-mcp/internal/fetch"
 	"github.com/AgentDank/dank-mcp/internal/mcp"
+	"github.com/AgentDank/dank-mcp/internal/refresh"
+	"github.com/AgentDank/dank-mcp/internal/retry"
 	"github.com/AgentDank/dank-mcp/internal/version"
+	"github.com/AgentDank/dank-mcp/pkg/dank"
 	"github.com/spf13/pflag"
 )
 
@@ -24,13 +31,18 @@ import (
 const (
 	mcpServerName = "dank-mcp"
 
-	defaultSSEHostPort = ":8889"
-	defaultDBFile      = "dank-mcp.duckdb"
-	_                  = "dank-mcp.log" // reserved for future --log-file default
+	defaultHostPort = ":8889"
+	defaultDBFile   = "dank-mcp.duckdb"
+	_               = "dank-mcp.log" // reserved for future --log-file default
+
+	defaultRefreshInterval = time.Hour // how often to re-fetch served datasets
 )
 
 type Config struct {
-	DuckDBFile string // DuckDB file to connect to
+	DuckDBFile   string // DuckDB file to connect to
+	BindingsPath string // Binding JSON file or directory of them
+
+	Hardening db.Hardening // DuckDB resource limits, applied before locking its configuration
 
 	LogJSON bool // Log in JSON format instead of text
 	Verbose bool // Verbose logging
@@ -41,36 +53,145 @@ type Config struct {
 ///////////////////////////////////////////////////////////////////////////////
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		os.Exit(runCacheCommand(os.Args[2:]))
+	}
+
 	var config Config
-	var dankRoot, logFilename string
+	var dankRoot, logFilename, apiKeysFilename string
 	var showHelp bool
+	var useSSE, useHTTP bool
 
 	pflag.StringVarP(&dankRoot, "root", "", "", "Set root location of '.dank' dir (Default: current dir)")
 	pflag.StringVarP(&config.DuckDBFile, "db", "", "", "DuckDB data file to use, use ':memory:' for in-memory. Default is '.dank/dank-mcp.duckdb' under --root")
+	pflag.StringVarP(&config.BindingsPath, "bindings", "", "", "Binding JSON file, or directory of *.json bindings, to register as MCP tools, resources and prompts")
 	pflag.StringVarP(&logFilename, "log-file", "l", "", "Log file destination (or MCP_LOG_FILE envvar). Default is stderr")
 	pflag.BoolVarP(&config.LogJSON, "log-json", "j", false, "Log in JSON (default is plaintext)")
-	pflag.StringVarP(&config.MCPConfig.SSEHostPort, "sse-host", "", "", "host:port to listen to SSE connections")
-	pflag.BoolVarP(&config.MCPConfig.UseSSE, "sse", "", false, "Use SSE Transport (default is STDIO transport)")
+	pflag.StringVarP(&config.MCPConfig.HostPort, "sse-host", "", "", "host:port to listen to SSE or HTTP connections (default \""+defaultHostPort+"\")")
+	pflag.BoolVarP(&useSSE, "sse", "", false, "Use SSE Transport (default is STDIO transport)")
+	pflag.BoolVarP(&useHTTP, "http", "", false, "Use Streamable HTTP Transport, served at "+mcp.HTTPEndpointPath+" (default is STDIO transport)")
+	pflag.BoolVarP(&config.MCPConfig.HTTPStateless, "http-stateless", "", false, "Serve Streamable HTTP without sessions (requires --http)")
+	pflag.StringVarP(&apiKeysFilename, "api-keys-file", "", "", "File of API keys required by --sse or --http, one 'name:key' per line (or MCP_API_KEYS envvar with the keys themselves)")
+	pflag.BoolVarP(&config.MCPConfig.InsecureNoAuth, "insecure-no-auth", "", false, "Serve --sse or --http without API keys on an address other hosts can reach")
 	pflag.BoolVarP(&config.Verbose, "verbose", "v", false, "Verbose logging")
-	var fetchID string
-	var fetchOnly, forceFetch bool
-	var listCatalog bool
-	pflag.StringVarP(&fetchID, "fetch", "", "", "Dataset id to download from dank-data (e.g., us/ct)")
+	pflag.IntVarP(&config.MCPConfig.ResultLimits.MaxRows, "max-rows", "", mcp.DefaultMaxRows, "Maximum rows in each tool or resource result, 0 for no limit")
+	pflag.IntVarP(&config.MCPConfig.ResultLimits.MaxBytes, "max-bytes", "", mcp.DefaultMaxBytes, "Maximum bytes in each tool or resource result, 0 for no limit")
+	pflag.StringVarP(&config.MCPConfig.Values.NullToken, "null-token", "", "", "Text for NULL values in CSV and Markdown results (default empty)")
+	pflag.StringVarP(&config.MCPConfig.Values.BlobEncoding, "blob-encoding", "", db.BlobHex, "Encoding of BLOB values in results: hex or base64")
+	pflag.DurationVarP(&config.MCPConfig.QueryTimeout, "query-timeout", "", mcp.DefaultQueryTimeout, "Maximum run time of each query, 0 for no limit")
+	pflag.StringVarP(&config.Hardening.MemoryLimit, "memory-limit", "", db.DefaultHardening.MemoryLimit, "Maximum memory DuckDB may use, e.g. 4GB; empty for DuckDB's default (80% of RAM)")
+	pflag.IntVarP(&config.Hardening.Threads, "threads", "", db.DefaultHardening.Threads, "Number of threads DuckDB may use, 0 for DuckDB's default (all cores)")
+	pflag.StringVarP(&config.Hardening.MaxTempDirectorySize, "max-temp-size", "", db.DefaultHardening.MaxTempDirectorySize, "Maximum disk DuckDB may spill to, e.g. 10GB; empty for DuckDB's default (90% of free space)")
+	var fetchIDs, catalogArgs, catalogKeyArgs []string
+	var fetchOnly, forceFetch, insecureCatalog bool
+	var refreshInterval, maxAge time.Duration
+	var refreshPolicyName string
+	var retries int
+	retryPolicy := retry.DefaultPolicy
+	var listCatalog, listInstalled bool
+	var listOpts listOptions
+	pflag.StringSliceVarP(&fetchIDs, "fetch", "", nil, "Dataset ids to download from dank-data, comma-separated or repeated (e.g., us/ct,us/ma)")
 	pflag.BoolVarP(&fetchOnly, "fetch-only", "", false, "Download only; do not start the MCP server")
 	pflag.BoolVarP(&forceFetch, "force", "", false, "Force re-download even if cache is fresh (requires --fetch)")
-	pflag.BoolVarP(&listCatalog, "list", "", false, "List datasets from the dank-data catalog and exit")
+	pflag.StringVarP(&refreshPolicyName, "refresh", "", string(fetch.RefreshTTL), "When to check for newer snapshots: 'ttl' once older than --max-age, 'catalog' every time, 'never' if installed, or 'always' re-download")
+	pflag.DurationVarP(&maxAge, "max-age", "", fetch.DefaultMaxAge, "How old an installed snapshot may be before --refresh=ttl checks for a newer one")
+	pflag.DurationVarP(&refreshInterval, "refresh-interval", "", defaultRefreshInterval, "How often to re-fetch the --fetch datasets, serving newer snapshots without a restart; 0 to disable")
+	pflag.IntVarP(&retries, "retries", "", retry.DefaultPolicy.Attempts-1, "Number of times to retry catalog and snapshot requests that fail with a 429, 5xx or connection error")
+	pflag.DurationVarP(&retryPolicy.Backoff, "retry-backoff", "", retry.DefaultPolicy.Backoff, "Delay before the first retry, doubled for each further retry, with jitter")
+	pflag.StringArrayVarP(&catalogArgs, "catalog", "", nil, "Catalog to find datasets in: an http(s) or file:// URL, a catalog.json, a directory containing one, or 'default'; repeat to merge several, earlier ones taking precedence (default dank-data's)")
+	pflag.StringArrayVarP(&catalogKeyArgs, "catalog-key", "", nil, "Minisign public key, or .pub file of one, trusted to sign catalogs along with the pinned key; may be repeated")
+	pflag.BoolVarP(&insecureCatalog, "insecure-catalog", "", false, "Accept catalogs without a valid signature by a trusted key")
+	pflag.BoolVarP(&listCatalog, "list", "", false, "List datasets from the dank-data catalog, and whether they are installed and current, and exit")
+	pflag.StringVarP(&listOpts.Output, "output", "o", listFormats[0], "Format of --list: "+strings.Join(listFormats, ", "))
+	pflag.StringSliceVarP(&listOpts.Filter.Regions, "region", "", nil, "List only datasets in these regions: id or jurisdiction prefixes, e.g. 'us' or 'US-CT' (with --list)")
+	pflag.StringVarP(&listOpts.Filter.Search, "search", "", "", "List only datasets mentioning this text in their id, title, description, tags or tables (with --list)")
+	pflag.StringVarP(&listOpts.Sort, "sort", "", listSorts[0], "Order of --list: 'id', or 'updated' for the newest first")
+	pflag.BoolVarP(&listInstalled, "installed", "", false, "List downloaded datasets with the catalog, sha256 and time of their snapshots, and exit")
 	pflag.BoolVarP(&showHelp, "help", "h", false, "Show help")
 	pflag.Parse()
 	dbFlagSet := pflag.Lookup("db").Changed
 
-	if forceFetch && fetchID == "" {
+	if forceFetch && len(fetchIDs) == 0 {
 		fmt.Fprintln(os.Stderr, "--force requires --fetch <id>")
 		os.Exit(2)
 	}
-	if fetchOnly && fetchID == "" {
+	if fetchOnly && len(fetchIDs) == 0 {
 		fmt.Fprintln(os.Stderr, "--fetch-only requires --fetch <id>")
 		os.Exit(2)
 	}
+	for _, name := range []string{"output", "region", "search", "sort"} {
+		if pflag.Lookup(name).Changed && !listCatalog {
+			fmt.Fprintf(os.Stderr, "--%s requires --list\n", name)
+			os.Exit(2)
+		}
+	}
+	if !slices.Contains(listFormats, listOpts.Output) {
+		fmt.Fprintf(os.Stderr, "--output must be one of %s\n", strings.Join(listFormats, ", "))
+		os.Exit(2)
+	}
+	if !slices.Contains(listSorts, listOpts.Sort) {
+		fmt.Fprintf(os.Stderr, "--sort must be one of %s\n", strings.Join(listSorts, ", "))
+		os.Exit(2)
+	}
+
+	if err := config.MCPConfig.Values.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "--blob-encoding: %s\n", err.Error())
+		os.Exit(2)
+	}
+	if err := config.Hardening.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "--threads: %s\n", err.Error())
+		os.Exit(2)
+	}
+
+	refreshPolicy, err := fetch.ParseRefreshPolicy(refreshPolicyName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "--refresh: %s\n", err.Error())
+		os.Exit(2)
+	}
+	if maxAge <= 0 {
+		fmt.Fprintln(os.Stderr, "--max-age must be positive")
+		os.Exit(2)
+	}
+	if retries < 0 {
+		fmt.Fprintln(os.Stderr, "--retries must not be negative")
+		os.Exit(2)
+	}
+	retryPolicy.Attempts = retries + 1
+	catalogURLs := make([]string, 0, len(catalogArgs))
+	for _, arg := range catalogArgs {
+		location, err := catalog.Location(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--catalog: %s\n", err.Error())
+			os.Exit(2)
+		}
+		catalogURLs = append(catalogURLs, location)
+	}
+	if len(catalogURLs) == 0 {
+		catalogURLs = append(catalogURLs, catalog.DefaultURL)
+	}
+	var catalogKeys catalog.Keyring // nil with --insecure-catalog, so signatures are not checked
+	if insecureCatalog && len(catalogKeyArgs) > 0 {
+		fmt.Fprintln(os.Stderr, "--catalog-key and --insecure-catalog are mutually exclusive")
+		os.Exit(2)
+	}
+	if !insecureCatalog {
+		if catalogKeys, err = catalog.DefaultKeyring(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			os.Exit(1)
+		}
+		for _, arg := range catalogKeyArgs {
+			key, err := catalog.ReadPublicKey(arg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "--catalog-key: %s\n", err.Error())
+				os.Exit(2)
+			}
+			catalogKeys = append(catalogKeys, key)
+		}
+		if len(catalogKeys) == 0 && (listCatalog || len(fetchIDs) > 0) {
+			fmt.Fprintln(os.Stderr, "no catalog key is pinned; pass dank-data's, or your catalog's, with --catalog-key")
+			os.Exit(2)
+		}
+	}
 
 	if showHelp {
 		fmt.Fprintf(os.Stdout, "dank-mcp v%s\nusage: %s [opts]\n\n", version.Get(), os.Args[0])
@@ -78,34 +199,75 @@ func main() {
 		os.Exit(0)
 	}
 
+	if dankRoot != "" {
+		data.SetDankRoot(dankRoot)
+	}
 	if listCatalog {
-		cat, err := catalog.Fetch(context.Background(), catalog.DefaultURL, nil)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to fetch catalog: %s\n", err.Error())
-			os.Exit(1)
+		os.Exit(runList(catalogURLs, catalogKeys, retryPolicy, data.GetDankCacheDir(), listOpts))
+	}
+
+	switch {
+	case useSSE && useHTTP:
+		fmt.Fprintln(os.Stderr, "--sse and --http are mutually exclusive")
+		os.Exit(2)
+	case useSSE:
+		config.MCPConfig.Transport = mcp.TransportSSE
+	case useHTTP:
+		config.MCPConfig.Transport = mcp.TransportHTTP
+	default:
+		config.MCPConfig.Transport = mcp.TransportStdio
+	}
+	seenAliases := make(map[string]string, len(fetchIDs))
+	for _, fetchID := range fetchIDs {
+		alias := data.DatasetAlias(fetchID)
+		if other, ok := seenAliases[alias]; ok {
+			fmt.Fprintf(os.Stderr, "--fetch: datasets %q and %q would both be attached as %q\n", other, fetchID, alias)
+			os.Exit(2)
 		}
-		ids := make([]string, 0, len(cat.Datasets))
-		for id := range cat.Datasets {
-			ids = append(ids, id)
+		seenAliases[alias] = fetchID
+	}
+	if config.MCPConfig.HTTPStateless && !useHTTP {
+		fmt.Fprintln(os.Stderr, "--http-stateless requires --http")
+		os.Exit(2)
+	}
+	if config.MCPConfig.HostPort == "" {
+		config.MCPConfig.HostPort = defaultHostPort
+	}
+	if apiKeysFilename != "" { // prefer CLI option
+		apiKeys, err := mcp.LoadAPIKeys(apiKeysFilename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--api-keys-file: %s\n", err.Error())
+			os.Exit(2)
 		}
-		sort.Strings(ids)
-		fmt.Fprintln(os.Stdout, "ID\tTITLE\tUPDATED\tDESCRIPTION")
-		for _, id := range ids {
-			e := cat.Datasets[id]
-			fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\n", id, e.Title, e.UpdatedAt, e.Description)
+		config.MCPConfig.APIKeys = apiKeys
+	} else if apiKeysText, ok := os.LookupEnv("MCP_API_KEYS"); ok && (apiKeysText != "" || config.MCPConfig.Transport != mcp.TransportStdio) {
+		// Set but empty is an error too when serving over the network, rather than no authentication
+		apiKeys, err := mcp.ParseAPIKeys(apiKeysText)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "MCP_API_KEYS: %s\n", err.Error())
+			os.Exit(2)
 		}
-		os.Exit(0)
+		config.MCPConfig.APIKeys = apiKeys
 	}
-
-	if config.MCPConfig.SSEHostPort == "" {
-		config.MCPConfig.SSEHostPort = defaultSSEHostPort
+	if apiKeysFilename != "" && config.MCPConfig.Transport == mcp.TransportStdio {
+		fmt.Fprintln(os.Stderr, "--api-keys-file requires --sse or --http")
+		os.Exit(2)
+	}
+	if config.MCPConfig.InsecureNoAuth && config.MCPConfig.Transport == mcp.TransportStdio {
+		fmt.Fprintln(os.Stderr, "--insecure-no-auth requires --sse or --http")
+		os.Exit(2)
+	}
+	if err := config.MCPConfig.CheckAuth(); err != nil && !fetchOnly && !listCatalog && !listInstalled {
+		// Refuse before any download, rather than serve without authentication
+		fmt.Fprintf(os.Stderr, "%s\npass --api-keys-file or MCP_API_KEYS, --sse-host 127.0.0.1:8889, or --insecure-no-auth\n", err.Error())
+		os.Exit(2)
 	}
 
 	config.MCPConfig.Name = mcpServerName
 	config.MCPConfig.Version = version.Get()
 
-	if dankRoot != "" {
-		data.SetDankRoot(dankRoot)
+	if listInstalled {
+		os.Exit(listInstalledDatasets(data.GetDankCacheDir()))
 	}
 	if _, err := data.EnsureDankPath(); err != nil {
 		fmt.Fprintf(os.Stderr, "Cannot access Dank root dir:'%s' err:%s\n", data.GetDankDir(), err.Error())
@@ -139,27 +301,49 @@ func main() {
 	}
 
 	logger.Info("dank-mcp")
+	if insecureCatalog {
+		logger.Warn("catalog signatures are not verified (--insecure-catalog)")
+	}
 
 	// Optional data fetch from dank-data catalog.
-	if fetchID != "" {
+	fetched := make(map[string]string, len(fetchIDs)) // dataset id -> DuckDB path
+	for _, fetchID := range fetchIDs {
 		cachePath := data.GetDatasetCachePath(fetchID)
 		resolved, err := fetch.Download(context.Background(), fetchID, fetch.Options{
-			CachePath: cachePath,
-			Logger:    logger,
-			Force:     forceFetch,
+			Catalogs:    catalogURLs,
+			CatalogKeys: catalogKeys,
+			CachePath:   cachePath,
+			Retry:       &retryPolicy,
+			Logger:      logger,
+			Refresh:     refreshPolicy,
+			MaxAge:      maxAge,
+			Force:       forceFetch,
 		})
 		if err != nil {
 			logger.Error("fetch failed", "id", fetchID, "error", err.Error())
 			os.Exit(1)
 		}
-		if fetchOnly {
-			logger.Info("fetch-only complete", "id", fetchID, "path", resolved)
-			return
-		}
-		// If the user didn't explicitly pass --db, serve from the fetched file.
-		if !dbFlagSet {
-			config.DuckDBFile = resolved
+		fetched[fetchID] = resolved
+	}
+	if fetchOnly {
+		for _, fetchID := range fetchIDs {
+			logger.Info("fetch-only complete", "id", fetchID, "path", fetched[fetchID])
 		}
+		return
+	}
+
+	// If the user didn't explicitly pass --db, serve a single fetched dataset
+	// directly. Otherwise each fetched dataset is attached to the --db, or to
+	// an empty in-memory database, under a name derived from its id.
+	var attachIDs []string
+	switch {
+	case len(fetchIDs) == 1 && !dbFlagSet:
+		config.DuckDBFile = fetched[fetchIDs[0]]
+	case len(fetchIDs) > 1 && !dbFlagSet:
+		config.DuckDBFile = ":memory:"
+		attachIDs = fetchIDs
+	default:
+		attachIDs = fetchIDs
 	}
 
 	if config.DuckDBFile == "" {
@@ -167,35 +351,138 @@ func main() {
 	}
 
 	// Setup DuckDB
-	if config.DuckDBFile == ":memory:" {
+	if config.DuckDBFile == ":memory:" && len(attachIDs) == 0 {
 		logger.Warn("using in-memory database, no persistence")
 	}
-	duckdbConn, err := sql.Open("duckdb", config.DuckDBFile)
-	if err != nil {
-		logger.Error("failed to open duckdb", "error", err.Error())
-		os.Exit(1)
+	var attachments []db.Attachment
+	var defaultAlias string
+	var served []mcp.ServedDataset
+	if config.DuckDBFile != ":memory:" {
+		if _, err := os.Stat(config.DuckDBFile); errors.Is(err, fs.ErrNotExist) {
+			// Create the database, so it can be attached read-only
+			duckdbConn, err := sql.Open("duckdb", config.DuckDBFile)
+			if err != nil {
+				logger.Error("failed to open duckdb", "error", err.Error())
+				os.Exit(1)
+			}
+			duckdbConn.Close()
+		}
+		// Served as the default database, named like DuckDB names it
+		defaultAlias = db.FileAlias(config.DuckDBFile)
+		for _, fetchID := range attachIDs {
+			if data.DatasetAlias(fetchID) == defaultAlias {
+				logger.Error("--db would be attached under the same name as a dataset; rename the file",
+					"db", config.DuckDBFile, "dataset", fetchID, "database", defaultAlias)
+				os.Exit(2)
+			}
+		}
+		attachments = append(attachments, db.Attachment{Path: config.DuckDBFile, Alias: defaultAlias})
+		if len(attachIDs) == 0 && len(fetchIDs) == 1 {
+			served = append(served, mcp.ServedDataset{ID: fetchIDs[0], Path: config.DuckDBFile, Database: defaultAlias})
+		}
+	}
+	for _, fetchID := range attachIDs {
+		alias := data.DatasetAlias(fetchID)
+		attachments = append(attachments, db.Attachment{Path: fetched[fetchID], Alias: alias})
+		served = append(served, mcp.ServedDataset{ID: fetchID, Path: fetched[fetchID], Database: alias})
 	}
 
-	// Reload our DuckDB in read-only mode for security
-	duckdbConn.Close()
-	duckdbConnRO, err := sql.Open("duckdb", config.DuckDBFile+"?access_mode=read_only")
+	// Open our DuckDB in read-only mode for security, locked down further
+	// via safe-mode SQL and resource limits. The manifests of the datasets
+	// are read once they are open, to describe the snapshots served.
+	openDuckDB := func() (*sql.DB, []mcp.ServedManifest, error) {
+		conn, err := db.OpenReadOnly(attachments, defaultAlias, config.Hardening)
+		if err != nil {
+			return nil, nil, err
+		}
+		manifests, err := mcp.ReadManifests(served)
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		return conn, manifests, nil
+	}
+	duckdbConnRO, servedManifests, err := openDuckDB()
 	if err != nil {
 		logger.Error("failed to open duckdb read-only", "error", err.Error())
 		os.Exit(1)
 	}
-	defer duckdbConnRO.Close()
+	for _, fetchID := range attachIDs {
+		logger.Info("attached dataset", "id", fetchID, "database", data.DatasetAlias(fetchID))
+	}
+	swappable := db.NewSwappable(duckdbConnRO, servedManifests)
+	defer swappable.Close()
 
-	// Lock the connection down further via safe-mode SQL
-	if err = db.RunSafeMode(duckdbConnRO); err != nil {
-		logger.Error("failed to run safe mode", "error", err.Error())
-		os.Exit(1)
+	// Periodically re-fetch served datasets, swapping in newer snapshots
+	if len(fetched) > 0 && refreshInterval > 0 && refreshPolicy != fetch.RefreshNever {
+		refresher, err := refresh.New(refresh.Options[[]mcp.ServedManifest]{
+			Paths:    fetched,
+			Interval: refreshInterval,
+			Download: func(ctx context.Context, id string) error {
+				_, err := fetch.Download(ctx, id, fetch.Options{
+					Catalogs:    catalogURLs,
+					CatalogKeys: catalogKeys,
+					CachePath:   data.GetDatasetCachePath(id),
+					Retry:       &retryPolicy,
+					Logger:      logger,
+					Refresh:     refresh.DownloadPolicy(refreshPolicy),
+					MaxAge:      maxAge,
+				})
+				return err
+			},
+			Open:      openDuckDB,
+			Swappable: swappable,
+			Logger:    logger,
+		})
+		if err != nil {
+			logger.Error("failed to start refresher", "error", err.Error())
+			os.Exit(1)
+		}
+		refreshCtx, cancelRefresh := context.WithCancel(context.Background())
+		defer cancelRefresh()
+		go refresher.Run(refreshCtx)
+		logger.Info("refreshing datasets", "interval", refreshInterval.String())
+	}
+
+	// Assemble our tools, including any declared by bindings
+	tools := mcp.ToolMap{
+		"query":          mcp.RegisterQueryTool,
+		"list_tables":    mcp.RegisterListTablesTool,
+		"describe_table": mcp.RegisterDescribeTableTool,
+		"sample_rows":    mcp.RegisterSampleRowsTool,
+	}
+	if config.BindingsPath != "" {
+		bindings, err := dank.LoadBindings(config.BindingsPath)
+		if err != nil {
+			logger.Error("failed to load bindings", "path", config.BindingsPath, "error", err.Error())
+			os.Exit(1)
+		}
+		for name, registrar := range mcp.BindingTools(bindings) {
+			if _, exists := tools[name]; exists {
+				logger.Error("binding tool conflicts with built-in tool", "name", name)
+				os.Exit(1)
+			}
+			tools[name] = registrar
+		}
+		config.MCPConfig.Resources = mcp.BindingResources(bindings)
+		config.MCPConfig.Prompts = mcp.BindingPrompts(bindings)
+		logger.Info("loaded bindings", "path", config.BindingsPath, "count", len(bindings))
+	}
+	if len(served) > 0 {
+		if _, exists := config.MCPConfig.Resources[mcp.DatasetsResourceURI]; exists {
+			logger.Error("binding resource conflicts with built-in resource", "uri", mcp.DatasetsResourceURI)
+			os.Exit(1)
+		}
+		if config.MCPConfig.Resources == nil {
+			config.MCPConfig.Resources = make(mcp.ResourceMap)
+		}
+		config.MCPConfig.Resources[mcp.DatasetsResourceURI] = mcp.DatasetsResource(servedManifests)
 	}
 
 	// Run our MCP server
 	config.MCPConfig.DB = duckdbConnRO
-	err = mcp.RunRouter(config.MCPConfig, logger, mcp.ToolMap{
-		"query": mcp.RegisterQueryTool,
-	})
+	config.MCPConfig.Swappable = swappable
+	err = mcp.RunRouter(config.MCPConfig, logger, tools)
 	if err != nil {
 		logger.Error("MCP router error", "error", err.Error())
 		os.Exit(1)
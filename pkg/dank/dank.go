@@ -30,11 +30,12 @@ type Registrar interface {
 
 // Binding is collection of Resource/Prompts/Tools that are described together along with Data
 type Binding struct {
-	Name      string          `json:"name"`        // Unique, human-readable name of the Binding
-	Title     string          `json:"title"`       // The title of the Binding
-	Desc      string          `json:"description"` // The description of the Binding
-	Resources []ResourceQuery `json:"resources"`   // The list of tools to include in this binding
-	Tools     []ToolQuery     `json:"tools"`       // The list of tools to include in this binding
+	Name      string           `json:"name"`        // Unique, human-readable name of the Binding
+	Title     string           `json:"title"`       // The title of the Binding
+	Desc      string           `json:"description"` // The description of the Binding
+	Resources []ResourceQuery  `json:"resources"`   // The list of tools to include in this binding
+	Prompts   []PromptTemplate `json:"prompts"`     // The list of prompts to include in this binding
+	Tools     []ToolQuery      `json:"tools"`       // The list of tools to include in this binding
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -53,7 +54,29 @@ type ResourceQuery struct {
 ///////////////////////////////////////////////////////////////////////////////
 // Prompts
 
-// TODO
+// PromptArgument describes an argument accepted by a PromptTemplate.
+type PromptArgument struct {
+	Name     string `json:"name"`                  // Name of the argument, referenced as {{.name}} in message templates
+	Desc     string `json:"description,omitempty"` // The description of the argument
+	Type     string `json:"type,omitempty"`        // The JSON schema type of the argument: string (default), number, integer, or boolean
+	Required bool   `json:"required,omitempty"`    // Whether the argument must be provided
+}
+
+// PromptMessage is one message of a PromptTemplate.  It is either text or an
+// embedded resource; it is an error to set both text and resource.
+type PromptMessage struct {
+	Role     string `json:"role"`               // The role of the message: user or assistant
+	Text     string `json:"text,omitempty"`     // Go text/template of the message, executed with the prompt's arguments
+	Resource string `json:"resource,omitempty"` // The URI (or URI template) of a ResourceQuery in the same Binding to embed
+}
+
+// PromptTemplate specifies an MCP Prompt whose messages are templated with its arguments.
+type PromptTemplate struct {
+	Name      string           `json:"name"`                  // Unique, human-readable name of the Prompt
+	Desc      string           `json:"description,omitempty"` // The description of the Prompt
+	Arguments []PromptArgument `json:"arguments,omitempty"`   // The arguments of the Prompt
+	Messages  []PromptMessage  `json:"messages"`              // The messages of the Prompt, in order
+}
 
 ///////////////////////////////////////////////////////////////////////////////
 // Tools
@@ -70,4 +93,7 @@ type ToolQuery struct {
 	Desc        string          `json:"description,omitempty"` // The description of the Tool
 	InputSchema ToolInputSchema `json:"schema"`                // The JSON schema of the intput
 	Query       string          `json:"query"`                 // The SQL query to run to get the data
+	Timeout     string          `json:"timeout,omitempty"`     // Optional query timeout (e.g. "2m"), overriding the server default
+	MaxRows     int             `json:"maxRows,omitempty"`     // Optional limit on result rows, overriding the server default
+	MaxBytes    int             `json:"maxBytes,omitempty"`    // Optional limit on result bytes, overriding the server default
 }
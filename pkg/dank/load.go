@@ -0,0 +1,374 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package dank
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/yosida95/uritemplate/v3"
+)
+
+// toolNamePattern matches names acceptable to MCP hosts for tools and prompts.
+var toolNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// paramNamePattern matches input property names that can be bound as DuckDB
+// named parameters (referenced as $name in a ToolQuery's SQL), and prompt
+// argument names usable in templates.
+var paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// supportedParamTypes are the JSON schema property types that can be bound
+// as DuckDB prepared-statement parameters.
+var supportedParamTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+}
+
+// QueryMimeTypes are the MIME types a ResourceQuery's query results can be
+// rendered as. RawData may be of any MIME type.
+var QueryMimeTypes = []string{"text/csv", "application/json", "application/x-ndjson", "text/markdown"}
+
+///////////////////////////////////////////////////////////////////////////////
+
+// ParseBinding decodes a Binding JSON document and validates it.
+func ParseBinding(body []byte) (Binding, error) {
+	var b Binding
+	if err := json.Unmarshal(body, &b); err != nil {
+		return Binding{}, fmt.Errorf("decode binding: %w", err)
+	}
+	if err := b.Validate(); err != nil {
+		return Binding{}, err
+	}
+	return b, nil
+}
+
+// LoadBindings loads Bindings from path, which is either a single JSON file
+// or a directory whose *.json files are each loaded in lexical order.
+// Every Binding is validated, and tool names, resource URIs and prompt names
+// must be unique across all of them.
+func LoadBindings(path string) ([]Binding, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat bindings: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("list bindings: %w", err)
+		}
+		sort.Strings(files)
+	}
+
+	bindings := make([]Binding, 0, len(files))
+	toolFiles := make(map[string]string)
+	resourceFiles := make(map[string]string)
+	promptFiles := make(map[string]string)
+	for _, file := range files {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read binding: %w", err)
+		}
+		b, err := ParseBinding(body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for _, tq := range b.Tools {
+			if prev, ok := toolFiles[tq.Name]; ok {
+				return nil, fmt.Errorf("%s: tool %q already defined in %s", file, tq.Name, prev)
+			}
+			toolFiles[tq.Name] = file
+		}
+		for _, rq := range b.Resources {
+			if prev, ok := resourceFiles[rq.Uri]; ok {
+				return nil, fmt.Errorf("%s: resource %q already defined in %s", file, rq.Uri, prev)
+			}
+			resourceFiles[rq.Uri] = file
+		}
+		for _, pt := range b.Prompts {
+			if prev, ok := promptFiles[pt.Name]; ok {
+				return nil, fmt.Errorf("%s: prompt %q already defined in %s", file, pt.Name, prev)
+			}
+			promptFiles[pt.Name] = file
+		}
+		bindings = append(bindings, b)
+	}
+	return bindings, nil
+}
+
+// Validate checks that the Binding and everything it declares is well-formed.
+// Returns nil on success.
+func (b Binding) Validate() error {
+	if b.Name == "" {
+		return fmt.Errorf("binding missing required field name")
+	}
+	seenURIs := make(map[string]bool, len(b.Resources))
+	for i, rq := range b.Resources {
+		if err := rq.Validate(); err != nil {
+			return fmt.Errorf("binding %q resource %d: %w", b.Name, i, err)
+		}
+		if seenURIs[rq.Uri] {
+			return fmt.Errorf("binding %q: duplicate resource %q", b.Name, rq.Uri)
+		}
+		seenURIs[rq.Uri] = true
+	}
+	seenPrompts := make(map[string]bool, len(b.Prompts))
+	for i, pt := range b.Prompts {
+		if err := pt.Validate(); err != nil {
+			return fmt.Errorf("binding %q prompt %d: %w", b.Name, i, err)
+		}
+		if seenPrompts[pt.Name] {
+			return fmt.Errorf("binding %q: duplicate prompt %q", b.Name, pt.Name)
+		}
+		seenPrompts[pt.Name] = true
+		if err := b.validatePromptResources(pt); err != nil {
+			return fmt.Errorf("binding %q prompt %q: %w", b.Name, pt.Name, err)
+		}
+	}
+	seen := make(map[string]bool, len(b.Tools))
+	for i, tq := range b.Tools {
+		if err := tq.Validate(); err != nil {
+			return fmt.Errorf("binding %q tool %d: %w", b.Name, i, err)
+		}
+		if seen[tq.Name] {
+			return fmt.Errorf("binding %q: duplicate tool %q", b.Name, tq.Name)
+		}
+		seen[tq.Name] = true
+	}
+	return nil
+}
+
+// validatePromptResources checks that every resource pt embeds is declared in
+// b, and that each of its URI placeholders is a required argument of pt.
+func (b Binding) validatePromptResources(pt PromptTemplate) error {
+	for _, msg := range pt.Messages {
+		if msg.Resource == "" {
+			continue
+		}
+		rq, ok := b.LookupResource(msg.Resource)
+		if !ok {
+			return fmt.Errorf("resource %q is not declared in the binding", msg.Resource)
+		}
+		params, _ := rq.TemplateParams()
+		for _, param := range params {
+			arg, ok := pt.LookupArgument(param)
+			if !ok || !arg.Required {
+				return fmt.Errorf("resource %q placeholder %q must be a required argument", msg.Resource, param)
+			}
+		}
+	}
+	return nil
+}
+
+// LookupResource returns the ResourceQuery with the given Uri, if any.
+func (b Binding) LookupResource(uri string) (ResourceQuery, bool) {
+	for _, rq := range b.Resources {
+		if rq.Uri == uri {
+			return rq, true
+		}
+	}
+	return ResourceQuery{}, false
+}
+
+// Validate checks that the PromptTemplate has a usable name, well-formed
+// arguments, and at least one message whose text template parses.
+// Returns nil on success.
+func (pt PromptTemplate) Validate() error {
+	if !toolNamePattern.MatchString(pt.Name) {
+		return fmt.Errorf("invalid prompt name %q: must match %s", pt.Name, toolNamePattern.String())
+	}
+	seen := make(map[string]bool, len(pt.Arguments))
+	for _, arg := range pt.Arguments {
+		if !paramNamePattern.MatchString(arg.Name) {
+			return fmt.Errorf("prompt %q: invalid argument name %q: must match %s", pt.Name, arg.Name, paramNamePattern.String())
+		}
+		if seen[arg.Name] {
+			return fmt.Errorf("prompt %q: duplicate argument %q", pt.Name, arg.Name)
+		}
+		seen[arg.Name] = true
+		if !supportedParamTypes[arg.ArgType()] {
+			return fmt.Errorf("prompt %q: argument %q has unsupported type %q", pt.Name, arg.Name, arg.Type)
+		}
+	}
+	if len(pt.Messages) == 0 {
+		return fmt.Errorf("prompt %q has no messages", pt.Name)
+	}
+	for i, msg := range pt.Messages {
+		if msg.Role != "user" && msg.Role != "assistant" {
+			return fmt.Errorf("prompt %q message %d: role must be user or assistant, got %q", pt.Name, i, msg.Role)
+		}
+		if (msg.Text == "") == (msg.Resource == "") {
+			return fmt.Errorf("prompt %q message %d: must set exactly one of text or resource", pt.Name, i)
+		}
+		if msg.Text != "" {
+			if _, err := pt.ParseMessage(i); err != nil {
+				return fmt.Errorf("prompt %q message %d: %w", pt.Name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseMessage parses the text template of the i'th message.
+func (pt PromptTemplate) ParseMessage(i int) (*template.Template, error) {
+	tmpl, err := template.New(fmt.Sprintf("%s[%d]", pt.Name, i)).Option("missingkey=zero").Parse(pt.Messages[i].Text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid text template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// LookupArgument returns the PromptArgument with the given name, if any.
+func (pt PromptTemplate) LookupArgument(name string) (PromptArgument, bool) {
+	for _, arg := range pt.Arguments {
+		if arg.Name == name {
+			return arg, true
+		}
+	}
+	return PromptArgument{}, false
+}
+
+// ArgType returns the JSON schema type of the argument, defaulting to "string".
+func (arg PromptArgument) ArgType() string {
+	if arg.Type == "" {
+		return "string"
+	}
+	return arg.Type
+}
+
+// Validate checks that the ResourceQuery has a name, a valid URI (or URI
+// template), a MIME type, and exactly one of Query or RawData, and that a
+// Query's MIME type is one of QueryMimeTypes.
+// Returns nil on success.
+func (rq ResourceQuery) Validate() error {
+	if rq.Name == "" {
+		return fmt.Errorf("resource missing required field name")
+	}
+	if rq.Uri == "" {
+		return fmt.Errorf("resource %q missing required field uri", rq.Name)
+	}
+	if rq.MimeType == "" {
+		return fmt.Errorf("resource %q missing required field mimeType", rq.Name)
+	}
+	if rq.Query != "" && rq.RawData != "" {
+		return fmt.Errorf("resource %q sets both query and rawData", rq.Name)
+	}
+	if strings.TrimSpace(rq.Query) == "" && rq.RawData == "" {
+		return fmt.Errorf("resource %q must set one of query or rawData", rq.Name)
+	}
+	if rq.Query != "" && !slices.Contains(QueryMimeTypes, rq.MimeType) {
+		return fmt.Errorf("resource %q: unsupported mimeType %q for query, must be one of %s",
+			rq.Name, rq.MimeType, strings.Join(QueryMimeTypes, ", "))
+	}
+	params, err := rq.TemplateParams()
+	if err != nil {
+		return err
+	}
+	if len(params) > 0 && rq.Query == "" {
+		return fmt.Errorf("resource %q: URI template parameters require a query", rq.Name)
+	}
+	return nil
+}
+
+// IsTemplate returns true if the Uri is a URI template with {placeholders}.
+func (rq ResourceQuery) IsTemplate() bool {
+	return strings.ContainsAny(rq.Uri, "{}")
+}
+
+// TemplateParams returns the placeholder names of the Uri, which are bound to
+// the Query as named parameters. Returns nil if the Uri is not a template.
+func (rq ResourceQuery) TemplateParams() ([]string, error) {
+	if !rq.IsTemplate() {
+		return nil, nil
+	}
+	tmpl, err := uritemplate.New(rq.Uri)
+	if err != nil {
+		return nil, fmt.Errorf("resource %q has invalid URI template: %w", rq.Name, err)
+	}
+	names := tmpl.Varnames()
+	if len(names) == 0 {
+		return nil, fmt.Errorf("resource %q has invalid URI template: no placeholders", rq.Name)
+	}
+	for _, name := range names {
+		if !paramNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("resource %q: invalid placeholder %q: must match %s", rq.Name, name, paramNamePattern.String())
+		}
+	}
+	return names, nil
+}
+
+// Validate checks that the ToolQuery has a usable name, query and input schema.
+// Returns nil on success.
+func (tq ToolQuery) Validate() error {
+	if !toolNamePattern.MatchString(tq.Name) {
+		return fmt.Errorf("invalid tool name %q: must match %s", tq.Name, toolNamePattern.String())
+	}
+	if strings.TrimSpace(tq.Query) == "" {
+		return fmt.Errorf("tool %q missing required field query", tq.Name)
+	}
+	if err := tq.InputSchema.Validate(); err != nil {
+		return fmt.Errorf("tool %q schema: %w", tq.Name, err)
+	}
+	if tq.Timeout != "" {
+		if d, err := time.ParseDuration(tq.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("tool %q: invalid timeout %q: must be a positive duration such as \"30s\"", tq.Name, tq.Timeout)
+		}
+	}
+	if tq.MaxRows < 0 || tq.MaxBytes < 0 {
+		return fmt.Errorf("tool %q: maxRows and maxBytes must not be negative", tq.Name)
+	}
+	return nil
+}
+
+// QueryTimeout returns the tool's query timeout, or 0 if it uses the
+// server default. The timeout is assumed to have passed Validate.
+func (tq ToolQuery) QueryTimeout() time.Duration {
+	d, _ := time.ParseDuration(tq.Timeout)
+	return d
+}
+
+// Validate checks that the schema is an object whose properties are all
+// scalar types that can be bound as SQL parameters.
+// Returns nil on success.
+func (s ToolInputSchema) Validate() error {
+	if s.Type != "" && s.Type != "object" {
+		return fmt.Errorf("type must be \"object\", got %q", s.Type)
+	}
+	for name := range s.Properties {
+		if !paramNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid property name %q: must match %s", name, paramNamePattern.String())
+		}
+		propType := s.PropertyType(name)
+		if !supportedParamTypes[propType] {
+			return fmt.Errorf("property %q has unsupported type %q", name, propType)
+		}
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok {
+			return fmt.Errorf("required property %q is not declared in properties", name)
+		}
+	}
+	return nil
+}
+
+// PropertyType returns the JSON schema "type" of the named property, or ""
+// if the property is not declared or has no type.
+func (s ToolInputSchema) PropertyType(name string) string {
+	prop, ok := s.Properties[name].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	propType, _ := prop["type"].(string)
+	return propType
+}
@@ -0,0 +1,291 @@
+// Copyright (c) 2026 Neomantra Corp
+
+package dank
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validBinding = `{
+  "name": "ct-brands",
+  "title": "Connecticut Brands",
+  "description": "Tools over the CT brand registry.",
+  "tools": [
+    {
+      "name": "brands_by_thc",
+      "description": "Brands with THC at or above a threshold",
+      "schema": {
+        "type": "object",
+        "properties": {
+          "min_thc": {"type": "number", "description": "Minimum THC percentage"},
+          "limit": {"type": "integer"}
+        },
+        "required": ["min_thc"]
+      },
+      "query": "SELECT brand_name, thc FROM brands WHERE thc >= $min_thc LIMIT coalesce($limit, 10)"
+    }
+  ]
+}`
+
+func TestParseBinding_Valid(t *testing.T) {
+	b, err := ParseBinding([]byte(validBinding))
+	if err != nil {
+		t.Fatalf("ParseBinding: %v", err)
+	}
+	if b.Name != "ct-brands" {
+		t.Errorf("Name = %q", b.Name)
+	}
+	if len(b.Tools) != 1 {
+		t.Fatalf("len(Tools) = %d; want 1", len(b.Tools))
+	}
+	tq := b.Tools[0]
+	if got := tq.InputSchema.PropertyType("min_thc"); got != "number" {
+		t.Errorf("PropertyType(min_thc) = %q; want number", got)
+	}
+	if got := tq.InputSchema.PropertyType("missing"); got != "" {
+		t.Errorf("PropertyType(missing) = %q; want empty", got)
+	}
+}
+
+func TestParseBinding_Rejects(t *testing.T) {
+	cases := []struct {
+		name    string
+		old     string
+		new     string
+		wantErr string
+	}{
+		{"missing name", `"name": "ct-brands",`, ``, "name"},
+		{"bad tool name", `"name": "brands_by_thc"`, `"name": "brands by thc"`, "tool name"},
+		{"empty query", `"query": "SELECT brand_name`, `"query": " ", "x": "`, "query"},
+		{"non-object schema", `"type": "object"`, `"type": "array"`, "object"},
+		{"unsupported property type", `{"type": "integer"}`, `{"type": "array"}`, "unsupported type"},
+		{"bad property name", `"limit": {`, `"lim-it": {`, "property name"},
+		{"undeclared required", `"required": ["min_thc"]`, `"required": ["max_thc"]`, "max_thc"},
+		{"invalid json", `"tools": [`, `"tools": [[`, "decode"},
+		{"bad timeout", `"query": "SELECT brand_name`, `"timeout": "soon", "query": "SELECT brand_name`, "timeout"},
+		{"negative timeout", `"query": "SELECT brand_name`, `"timeout": "-1s", "query": "SELECT brand_name`, "timeout"},
+		{"negative maxRows", `"query": "SELECT brand_name`, `"maxRows": -1, "query": "SELECT brand_name`, "maxRows"},
+	}
+	for _, c := range cases {
+		body := strings.Replace(validBinding, c.old, c.new, 1)
+		if body == validBinding {
+			t.Fatalf("%s: replacement did not apply", c.name)
+		}
+		_, err := ParseBinding([]byte(body))
+		if err == nil {
+			t.Errorf("%s: expected error", c.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("%s: error %q should mention %q", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestParseBinding_RejectsDuplicateTool(t *testing.T) {
+	b, err := ParseBinding([]byte(validBinding))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Tools = append(b.Tools, b.Tools[0])
+	if err := b.Validate(); err == nil {
+		t.Fatal("expected duplicate tool error")
+	}
+}
+
+func TestLoadBindings_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ct.json")
+	if err := os.WriteFile(path, []byte(validBinding), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bindings, err := LoadBindings(path)
+	if err != nil {
+		t.Fatalf("LoadBindings: %v", err)
+	}
+	if len(bindings) != 1 || bindings[0].Name != "ct-brands" {
+		t.Errorf("unexpected bindings: %+v", bindings)
+	}
+}
+
+func TestLoadBindings_Dir(t *testing.T) {
+	dir := t.TempDir()
+	second := strings.Replace(validBinding, `"name": "ct-brands"`, `"name": "ct-more"`, 1)
+	second = strings.Replace(second, `"brands_by_thc"`, `"brands_by_thc_2"`, 1)
+	os.WriteFile(filepath.Join(dir, "b.json"), []byte(second), 0o644)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(validBinding), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644)
+
+	bindings, err := LoadBindings(dir)
+	if err != nil {
+		t.Fatalf("LoadBindings: %v", err)
+	}
+	if len(bindings) != 2 {
+		t.Fatalf("len = %d; want 2", len(bindings))
+	}
+	if bindings[0].Name != "ct-brands" || bindings[1].Name != "ct-more" {
+		t.Errorf("bindings not in lexical file order: %q, %q", bindings[0].Name, bindings[1].Name)
+	}
+}
+
+func TestLoadBindings_DirRejectsToolConflict(t *testing.T) {
+	dir := t.TempDir()
+	second := strings.Replace(validBinding, `"name": "ct-brands"`, `"name": "ct-more"`, 1)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(validBinding), 0o644)
+	os.WriteFile(filepath.Join(dir, "b.json"), []byte(second), 0o644)
+
+	_, err := LoadBindings(dir)
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if !strings.Contains(err.Error(), "brands_by_thc") {
+		t.Errorf("error should name the tool: %v", err)
+	}
+}
+
+func TestLoadBindings_Missing(t *testing.T) {
+	if _, err := LoadBindings(filepath.Join(t.TempDir(), "nope.json")); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestResourceQuery_Validate(t *testing.T) {
+	valid := ResourceQuery{Name: "brands", Uri: "dank://us/ct/brands", MimeType: "text/csv", Query: "SELECT * FROM brands"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		mutate  func(rq *ResourceQuery)
+		wantErr string
+	}{
+		{"missing name", func(rq *ResourceQuery) { rq.Name = "" }, "name"},
+		{"missing uri", func(rq *ResourceQuery) { rq.Uri = "" }, "uri"},
+		{"missing mimeType", func(rq *ResourceQuery) { rq.MimeType = "" }, "mimeType"},
+		{"both query and rawData", func(rq *ResourceQuery) { rq.RawData = "x" }, "both"},
+		{"neither query nor rawData", func(rq *ResourceQuery) { rq.Query = "" }, "one of"},
+		{"unsupported mimeType", func(rq *ResourceQuery) { rq.MimeType = "text/plain" }, "mimeType"},
+		{"bad template", func(rq *ResourceQuery) { rq.Uri = "dank://brands/{name" }, "template"},
+		{"bad placeholder", func(rq *ResourceQuery) { rq.Uri = "dank://brands/{na.me}" }, "placeholder"},
+		{"template without query", func(rq *ResourceQuery) {
+			rq.Uri = "dank://brands/{name}"
+			rq.Query = ""
+			rq.RawData = "x"
+		}, "require a query"},
+	}
+	for _, c := range cases {
+		rq := valid
+		c.mutate(&rq)
+		err := rq.Validate()
+		if err == nil {
+			t.Errorf("%s: expected error", c.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("%s: error %q should mention %q", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestResourceQuery_TemplateParams(t *testing.T) {
+	rq := ResourceQuery{Name: "brand", Uri: "dank://us/ct/brands/{brand}/{category}"}
+	params, err := rq.TemplateParams()
+	if err != nil {
+		t.Fatalf("TemplateParams: %v", err)
+	}
+	if len(params) != 2 || params[0] != "brand" || params[1] != "category" {
+		t.Errorf("params = %v", params)
+	}
+
+	rq.Uri = "dank://us/ct/brands"
+	if params, err := rq.TemplateParams(); err != nil || params != nil {
+		t.Errorf("static uri: params = %v, err = %v", params, err)
+	}
+}
+
+func TestParseBinding_RejectsDuplicateResource(t *testing.T) {
+	b, err := ParseBinding([]byte(validBinding))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rq := ResourceQuery{Name: "readme", Uri: "dank://readme", MimeType: "text/plain", RawData: "hi"}
+	b.Resources = []ResourceQuery{rq, rq}
+	if err := b.Validate(); err == nil {
+		t.Fatal("expected duplicate resource error")
+	}
+}
+
+const validPromptBinding = `{
+  "name": "ct-potency",
+  "resources": [
+    {
+      "name": "brand-potency",
+      "uri": "dank://us/ct/potency/{brand}",
+      "mimeType": "text/csv",
+      "query": "SELECT category, avg(thc) AS thc FROM brands WHERE brand_name = $brand GROUP BY category"
+    }
+  ],
+  "prompts": [
+    {
+      "name": "compare_potency",
+      "description": "Compare brand THC potency across categories",
+      "arguments": [
+        {"name": "brand", "description": "Brand name", "required": true},
+        {"name": "top", "type": "integer"}
+      ],
+      "messages": [
+        {"role": "user", "text": "Compare THC potency of {{.brand}} across categories."},
+        {"role": "user", "resource": "dank://us/ct/potency/{brand}"}
+      ]
+    }
+  ]
+}`
+
+func TestParseBinding_ValidPrompt(t *testing.T) {
+	b, err := ParseBinding([]byte(validPromptBinding))
+	if err != nil {
+		t.Fatalf("ParseBinding: %v", err)
+	}
+	if len(b.Prompts) != 1 || len(b.Prompts[0].Messages) != 2 {
+		t.Fatalf("unexpected prompts: %+v", b.Prompts)
+	}
+	arg, ok := b.Prompts[0].LookupArgument("brand")
+	if !ok || arg.ArgType() != "string" {
+		t.Errorf("LookupArgument(brand) = %+v, %v", arg, ok)
+	}
+}
+
+func TestParseBinding_RejectsBadPrompt(t *testing.T) {
+	cases := []struct {
+		name    string
+		old     string
+		new     string
+		wantErr string
+	}{
+		{"bad prompt name", `"name": "compare_potency"`, `"name": "compare potency"`, "prompt name"},
+		{"bad role", `{"role": "user", "text"`, `{"role": "system", "text"`, "role"},
+		{"text and resource", `"text": "Compare`, `"resource": "dank://x", "text": "Compare`, "exactly one"},
+		{"bad template", `{{.brand}}`, `{{.brand`, "template"},
+		{"bad argument type", `"type": "integer"`, `"type": "object"`, "unsupported type"},
+		{"undeclared resource", `"resource": "dank://us/ct/potency/{brand}"`, `"resource": "dank://nope"`, "not declared"},
+		{"optional placeholder", `"description": "Brand name", "required": true`, `"description": "Brand name"`, "required argument"},
+		{"no messages", `"messages": [`, `"messages": [], "x": [`, "no messages"},
+	}
+	for _, c := range cases {
+		body := strings.Replace(validPromptBinding, c.old, c.new, 1)
+		if body == validPromptBinding {
+			t.Fatalf("%s: replacement did not apply", c.name)
+		}
+		_, err := ParseBinding([]byte(body))
+		if err == nil {
+			t.Errorf("%s: expected error", c.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("%s: error %q should mention %q", c.name, err, c.wantErr)
+		}
+	}
+}